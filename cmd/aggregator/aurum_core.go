@@ -8,20 +8,32 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
+
+	"aurum-oracle/pkg/canonjson"
+	"aurum-oracle/pkg/remotesigner"
 )
 
 // --- Types ---
 
 type Transaction struct {
-	TxHash    string                 `json:"tx_hash"`
-	Timestamp int64                  `json:"timestamp"`
-	Data      map[string]interface{} `json:"data"`
+	TxHash    string          `json:"tx_hash"`
+	Timestamp int64           `json:"timestamp"`
+	Data      json.RawMessage `json:"data"` // typed payload, see Payload()
+	// Encoding is the byte form TxHash covers: "jcs" (RFC 8785), or empty
+	// for transactions hashed over encoding/json output. It is committed to
+	// through the transaction's Merkle leaf (see merkleLeaf)
+	Encoding string `json:"encoding,omitempty"`
 }
 
+// txEncodingJCS marks transactions hashed over the RFC 8785 form of Data.
+const txEncodingJCS = "jcs"
+
 type Block struct {
 	Index        int64         `json:"index"`
 	Timestamp    int64         `json:"timestamp"`
@@ -32,26 +44,55 @@ type Block struct {
 	Signature    string        `json:"signature"`
 	SignerPubkey string        `json:"signer_pubkey"`
 	Locked       bool          `json:"locked"`
+	// Signatures carries every validator signature over the header (multi-signer mode)
+	Signatures []BlockSignature `json:"signatures,omitempty"`
+}
+
+type BlockSignature struct {
+	Pubkey    string `json:"pubkey"`
+	Signature string `json:"signature"`
 }
 
 // --- Engine ---
 
 type AurumCore struct {
-	mu         sync.RWMutex
-	blocks     []Block
-	filepath   string
-	privateKey ed25519.PrivateKey
-	publicKey  ed25519.PublicKey
+	mu        sync.RWMutex
+	blocks    []Block
+	filepath  string
+	signer    Signer
+	publicKey ed25519.PublicKey
+	// appended is closed and replaced every time a block lands (see WaitForBlock)
+	appended chan struct{}
+	// finalizers must all accept a freshly minted block before it is persisted
+	finalizers []func(b *Block) error
+	// proposal is the last block we signed but could not persist. Signers
+	// never sign two headers at one height, so every retry re-proposes it as
+	// is; it is kept next to the ledger to survive a restart (see proposalPath)
+	proposal *Block
+	// validatorEpochs, once the ledger records a validator genesis, replace
+	// the single-signer check with an M-of-N quorum; the set in force changes
+	// with on-ledger validator changes. bootstrap is the configured set this
+	// node proposes as the genesis while the ledger has none
+	vmu             sync.RWMutex
+	validatorEpochs []validatorEpoch
+	bootstrap       *ValidatorSet
+	// checkpointsFrom is the first height minted with typed payloads; blocks
+	// below it predate checkpoints and carry none (see SetCheckpointsFrom)
+	checkpointsFrom int64
 }
 
-func NewAurumCore(filepath string, privKey ed25519.PrivateKey) *AurumCore {
+const genesisPrevHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+func NewAurumCore(filepath string, signer Signer) *AurumCore {
 	core := &AurumCore{
-		blocks:     []Block{},
-		filepath:   filepath,
-		privateKey: privKey,
-		publicKey:  privKey.Public().(ed25519.PublicKey),
+		blocks:    []Block{},
+		filepath:  filepath,
+		signer:    signer,
+		publicKey: signer.PublicKey(),
+		appended:  make(chan struct{}),
 	}
 	core.loadFromDisk()
+	core.loadProposal()
 	return core
 }
 
@@ -64,7 +105,7 @@ func (core *AurumCore) ComputeMerkleRoot(txs []Transaction) string {
 	}
 	hashes := make([]string, len(txs))
 	for i, tx := range txs {
-		hashes[i] = tx.TxHash
+		hashes[i] = merkleLeaf(tx)
 	}
 	// Simple Merkle Tree construction
 	for len(hashes) > 1 {
@@ -83,12 +124,34 @@ func (core *AurumCore) ComputeMerkleRoot(txs []Transaction) string {
 	return hashes[0]
 }
 
-func (core *AurumCore) SignBlock(b *Block) {
-	// Format: AURUM|v1|Index|PrevHash|MerkleRoot
-	msg := fmt.Sprintf("AURUM|v1|%d|%s|%s", b.Index, b.PreviousHash, b.MerkleRoot)
-	sig := ed25519.Sign(core.privateKey, []byte(msg))
+// merkleLeaf is the tree's leaf for tx. TxHash only covers Data, so a
+// transaction with an Encoding commits to it here: relabelling the encoding
+// changes the root. Transactions without one keep their bare TxHash.
+func merkleLeaf(tx Transaction) string {
+	if tx.Encoding == "" {
+		return tx.TxHash
+	}
+	h := sha256.Sum256([]byte(tx.Encoding + ":" + tx.TxHash))
+	return hex.EncodeToString(h[:])
+}
+
+// signingMessage is the header commitment every signer signs.
+// Format: AURUM|v1|Index|PrevHash|MerkleRoot
+func signingMessage(b *Block) []byte {
+	return remotesigner.HeaderMessage(b.Index, b.PreviousHash, b.MerkleRoot)
+}
+
+func (core *AurumCore) SignBlock(b *Block) error {
+	sig, err := core.signer.Sign(signingMessage(b))
+	if err != nil {
+		return fmt.Errorf("sign block %d: %w", b.Index, err)
+	}
 	b.Signature = hex.EncodeToString(sig)
 	b.SignerPubkey = hex.EncodeToString(core.publicKey)
+	if core.multiSigner() {
+		b.Signatures = []BlockSignature{{Pubkey: b.SignerPubkey, Signature: b.Signature}}
+	}
+	return nil
 }
 
 func (core *AurumCore) HashBlock(b *Block) string {
@@ -97,51 +160,304 @@ func (core *AurumCore) HashBlock(b *Block) string {
 	return hex.EncodeToString(h[:])
 }
 
-// --- Storage Operations ---
+// hashTransactionData hashes a payload in the given encoding.
+func hashTransactionData(data []byte, encoding string) (string, error) {
+	var txBytes []byte
+	var err error
+	switch encoding {
+	case txEncodingJCS:
+		txBytes, err = canonjson.Canonicalize(data)
+	case "":
+		txBytes, err = legacyCanonicalTx(data)
+	default:
+		err = fmt.Errorf("unknown transaction encoding %q", encoding)
+	}
+	if err != nil {
+		return "", err
+	}
+	txHash := sha256.Sum256(txBytes)
+	return hex.EncodeToString(txHash[:]), nil
+}
 
-func (core *AurumCore) AppendBlock(data map[string]interface{}) (*Block, error) {
-	core.mu.Lock()
-	defer core.mu.Unlock()
+// VerifyBlock checks that b correctly extends prev (nil for genesis): height,
+// hash link, transaction hashes, Merkle root, block hash and signature(s).
+// trusted decides whether a single signer is acceptable while the ledger
+// records no validator set.
+func (core *AurumCore) VerifyBlock(prev *Block, b *Block, trusted func(pubkey string) bool) error {
+	if err := core.verifyContents(prev, b); err != nil {
+		return err
+	}
+	vs := core.ValidatorSetAt(b.Index)
+	if vs == nil {
+		if !trusted(b.SignerPubkey) {
+			return fmt.Errorf("block %d: untrusted signer %s", b.Index, b.SignerPubkey)
+		}
+		if !verifySignature(b.SignerPubkey, b.Signature, signingMessage(b)) {
+			return fmt.Errorf("block %d: invalid signature", b.Index)
+		}
+		// The block recording the validator genesis must also reach its quorum
+		if vs = validatorGenesisIn(b); vs == nil {
+			return nil
+		}
+	}
+	if err := vs.VerifyQuorum(b); err != nil {
+		return err
+	}
+	return verifyValidatorChanges(b, vs)
+}
+
+// verifyContents checks everything about b except who signed it.
+func (core *AurumCore) verifyContents(prev *Block, b *Block) error {
+	expectedIndex, expectedPrev := int64(0), genesisPrevHash
+	if prev != nil {
+		expectedIndex, expectedPrev = prev.Index+1, prev.Hash
+	}
+	if b.Index != expectedIndex {
+		return fmt.Errorf("block %d: expected height %d", b.Index, expectedIndex)
+	}
+	if b.PreviousHash != expectedPrev {
+		return fmt.Errorf("block %d: previous hash does not link to %s", b.Index, expectedPrev)
+	}
+	for i, tx := range b.Transactions {
+		if h, err := hashTransactionData(tx.Data, tx.Encoding); err != nil || h != tx.TxHash {
+			return fmt.Errorf("block %d: tx %d hash mismatch", b.Index, i)
+		}
+		if _, err := tx.Payload(); err != nil {
+			return fmt.Errorf("block %d: tx %d: %w", b.Index, i, err)
+		}
+	}
+	if err := core.verifyCheckpoint(b); err != nil {
+		return err
+	}
+	if err := core.verifyValidatorGenesis(b); err != nil {
+		return err
+	}
+	if core.ComputeMerkleRoot(b.Transactions) != b.MerkleRoot {
+		return fmt.Errorf("block %d: merkle root mismatch", b.Index)
+	}
+	if core.HashBlock(b) != b.Hash {
+		return fmt.Errorf("block %d: hash mismatch", b.Index)
+	}
+	return nil
+}
 
+// verifySignature checks a hex ed25519 signature against a hex public key.
+func verifySignature(pubHex, sigHex string, msg []byte) bool {
+	pub, err := hex.DecodeString(pubHex)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	sig, err := hex.DecodeString(sigHex)
+	return err == nil && ed25519.Verify(ed25519.PublicKey(pub), msg, sig)
+}
+
+// --- Storage Operations ---
+
+// AppendBlock mints a block holding one transaction per payload, plus the
+// checkpoint its height calls for.
+func (core *AurumCore) AppendBlock(payloads ...TxPayload) (*Block, error) {
 	// 1. Determine Height and PrevHash
 	var index int64 = 0
-	prevHash := "0000000000000000000000000000000000000000000000000000000000000000"
+	prevHash := genesisPrevHash
+	core.mu.RLock()
 	if len(core.blocks) > 0 {
 		last := core.blocks[len(core.blocks)-1]
 		index = last.Index + 1
 		prevHash = last.Hash
 	}
+	finalizers := core.finalizers
+	proposal := core.proposal
+	core.mu.RUnlock()
 
-	// 2. Construct Tx
-	txBytes, _ := json.Marshal(data)
-	txHash := sha256.Sum256(txBytes)
-	tx := Transaction{
-		TxHash:    hex.EncodeToString(txHash[:]),
-		Timestamp: time.Now().Unix(),
-		Data:      data,
+	// 2. Re-propose the block a failed attempt already signed, however old,
+	// or build one. The proposal is on disk before any signature leaves
+	var block Block
+	if proposal != nil && proposal.Index == index && proposal.PreviousHash == prevHash {
+		block = *proposal
+	} else {
+		var err error
+		if block, err = core.buildBlock(index, prevHash, payloads); err != nil {
+			return nil, err
+		}
+		core.mu.Lock()
+		core.proposal = &block
+		core.mu.Unlock()
+		data, _ := json.Marshal(block)
+		if err := writeFileAtomic(core.proposalPath(), data); err != nil {
+			return nil, fmt.Errorf("persist proposal: %w", err)
+		}
 	}
 
-	// 3. Construct Block
+	// 3. Finalizers (co-signing, fencing) talk to the network, so they run
+	// without holding the ledger lock
+	for _, finalize := range finalizers {
+		if err := finalize(&block); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Persist, provided nobody extended the chain meanwhile
+	core.mu.Lock()
+	defer core.mu.Unlock()
+	if int64(len(core.blocks)) != index {
+		return nil, fmt.Errorf("chain advanced to height %d while minting block %d", len(core.blocks), index)
+	}
+	if err := core.writeToDisk(block); err != nil {
+		return nil, err
+	}
+
+	core.blocks = append(core.blocks, block)
+	core.proposal = nil
+	if err := os.Remove(core.proposalPath()); err != nil && !os.IsNotExist(err) {
+		log.Printf("⚠️  Remove proposal: %v", err)
+	}
+	core.applyValidatorChanges(&block)
+	core.notifyAppended()
+	return &block, nil
+}
+
+func (core *AurumCore) proposalPath() string { return core.filepath + ".proposal" }
+
+// loadProposal restores a block signed before a restart, provided it still
+// extends the ledger. A proposal the ledger has moved past is dropped.
+func (core *AurumCore) loadProposal() {
+	data, err := os.ReadFile(core.proposalPath())
+	if os.IsNotExist(err) {
+		return
+	}
+	var b Block
+	if err == nil {
+		err = json.Unmarshal(data, &b)
+	}
+	if err != nil {
+		// Building a different block at that height would be a double sign
+		log.Fatalf("❌ Proposal %s: %v", core.proposalPath(), err)
+	}
+	prevHash := genesisPrevHash
+	if n := len(core.blocks); n > 0 {
+		prevHash = core.blocks[n-1].Hash
+	}
+	if b.Index != int64(len(core.blocks)) || b.PreviousHash != prevHash {
+		os.Remove(core.proposalPath())
+		return
+	}
+	core.proposal = &b
+	log.Printf("♻️  Core: Restored unfinalized proposal #%d", b.Index)
+}
+
+// buildBlock assembles and signs a new block at index.
+func (core *AurumCore) buildBlock(index int64, prevHash string, payloads []TxPayload) (Block, error) {
+	if c := core.checkpointFor(index, prevHash); c != nil {
+		payloads = append(payloads, c)
+	}
+	if g := core.pendingGenesis(index); g != nil {
+		payloads = append(payloads, g)
+	}
+
+	// Construct Txs
+	var txs []Transaction
+	for _, p := range payloads {
+		tx, err := newTransaction(p)
+		if err != nil {
+			return Block{}, err
+		}
+		txs = append(txs, tx)
+	}
+
+	// Construct Block
 	block := Block{
 		Index:        index,
 		Timestamp:    time.Now().Unix(),
 		PreviousHash: prevHash,
-		Transactions: []Transaction{tx},
+		Transactions: txs,
 		Locked:       true, // Default to locked until verified
 	}
 
-	// 4. Finalize Crypto
+	// Finalize Crypto
 	block.MerkleRoot = core.ComputeMerkleRoot(block.Transactions)
 	block.Hash = core.HashBlock(&block)
-	core.SignBlock(&block)
+	if err := core.SignBlock(&block); err != nil {
+		return Block{}, err
+	}
+	return block, nil
+}
 
-	// 5. Persist
-	if err := core.writeToDisk(block); err != nil {
-		return nil, err
+// newTransaction encodes p canonically and hashes it.
+func newTransaction(p TxPayload) (Transaction, error) {
+	data, err := encodeTx(p)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("encode %s: %w", p.TxType(), err)
 	}
+	hash, err := hashTransactionData(data, txEncodingJCS)
+	if err != nil {
+		return Transaction{}, err
+	}
+	return Transaction{TxHash: hash, Timestamp: time.Now().Unix(), Data: data, Encoding: txEncodingJCS}, nil
+}
 
-	core.blocks = append(core.blocks, block)
-	return &block, nil
+// AddFinalizer registers a hook that must accept a freshly minted block
+// before it is persisted. Hooks run in registration order.
+func (core *AurumCore) AddFinalizer(fn func(b *Block) error) {
+	core.mu.Lock()
+	defer core.mu.Unlock()
+	core.finalizers = append(core.finalizers, fn)
+}
+
+// AppendVerified stores a block produced elsewhere (replication) after
+// checking it extends the local tip.
+func (core *AurumCore) AppendVerified(b Block, trusted func(pubkey string) bool) error {
+	core.mu.Lock()
+	defer core.mu.Unlock()
+
+	var prev *Block
+	if len(core.blocks) > 0 {
+		prev = &core.blocks[len(core.blocks)-1]
+	}
+	if err := core.VerifyBlock(prev, &b, trusted); err != nil {
+		return err
+	}
+	if err := core.writeToDisk(b); err != nil {
+		return err
+	}
+	core.blocks = append(core.blocks, b)
+	core.applyValidatorChanges(&b)
+	core.notifyAppended()
+	return nil
+}
+
+// notifyAppended wakes every WaitForBlock caller. Caller holds core.mu.
+func (core *AurumCore) notifyAppended() {
+	close(core.appended)
+	core.appended = make(chan struct{})
+}
+
+// WaitForBlock returns a channel that is closed when the next block is appended.
+func (core *AurumCore) WaitForBlock() <-chan struct{} {
+	core.mu.RLock()
+	defer core.mu.RUnlock()
+	return core.appended
+}
+
+func (core *AurumCore) Height() int64 {
+	core.mu.RLock()
+	defer core.mu.RUnlock()
+	return int64(len(core.blocks))
+}
+
+// BlocksFrom returns up to limit blocks starting at height from.
+func (core *AurumCore) BlocksFrom(from int64, limit int) []Block {
+	core.mu.RLock()
+	defer core.mu.RUnlock()
+	if from < 0 || from >= int64(len(core.blocks)) {
+		return []Block{}
+	}
+	end := from + int64(limit)
+	if end > int64(len(core.blocks)) {
+		end = int64(len(core.blocks))
+	}
+	out := make([]Block, end-from)
+	copy(out, core.blocks[from:end])
+	return out
 }
 
 func (core *AurumCore) GetLatest() Block {
@@ -156,15 +472,42 @@ func (core *AurumCore) GetLatest() Block {
 func (core *AurumCore) GetChainStatus() map[string]interface{} {
 	core.mu.RLock()
 	defer core.mu.RUnlock()
+	latestHash := ""
+	var latestTimestamp int64
+	if len(core.blocks) > 0 {
+		latestHash = core.blocks[len(core.blocks)-1].Hash
+		latestTimestamp = core.blocks[len(core.blocks)-1].Timestamp
+	}
 	return map[string]interface{}{
-		"height":      len(core.blocks),
-		"latest_hash": core.blocks[len(core.blocks)-1].Hash,
-		"integrity":   "secure",
+		"height":           len(core.blocks),
+		"latest_hash":      latestHash,
+		"latest_timestamp": latestTimestamp,
+		"integrity":        "secure",
 	}
 }
 
 // --- Binary I/O (.dat format) ---
 
+// writeFileAtomic replaces path with data, synced, so a crash leaves either
+// the old or the new contents.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+	return os.Rename(tmp.Name(), path)
+}
+
 func (core *AurumCore) writeToDisk(b Block) error {
 	f, err := os.OpenFile(core.filepath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
@@ -173,9 +516,9 @@ func (core *AurumCore) writeToDisk(b Block) error {
 	defer f.Close()
 
 	buf := new(bytes.Buffer)
-	
+
 	// Simple Binary Encoding: [Size(4)][JSONBytes(N)]
-	// In a real C++ daemon, we would use a packed binary struct, 
+	// In a real C++ daemon, we would use a packed binary struct,
 	// but for Go interoperability JSON is safer for now.
 	data, _ := json.Marshal(b)
 	binary.Write(buf, binary.LittleEndian, int32(len(data)))
@@ -205,7 +548,7 @@ func (core *AurumCore) loadFromDisk() {
 		}
 
 		data := make([]byte, length)
-		_, err = f.Read(data)
+		_, err = io.ReadFull(f, data)
 		if err != nil {
 			break
 		}
@@ -213,6 +556,7 @@ func (core *AurumCore) loadFromDisk() {
 		var b Block
 		json.Unmarshal(data, &b)
 		core.blocks = append(core.blocks, b)
+		core.applyValidatorChanges(&core.blocks[len(core.blocks)-1])
 	}
 	log.Printf("📚 Core: Loaded %d blocks from secure storage", len(core.blocks))
-}
\ No newline at end of file
+}
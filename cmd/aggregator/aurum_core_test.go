@@ -0,0 +1,274 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSigner(t *testing.T, seed byte) *LocalSigner {
+	t.Helper()
+	s, err := NewLocalSigner(ed25519.NewKeyFromSeed([]byte(strings.Repeat(string(seed), ed25519.SeedSize))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newTestCore(t *testing.T, signer Signer) *AurumCore {
+	t.Helper()
+	return NewAurumCore(filepath.Join(t.TempDir(), "chain.dat"), signer)
+}
+
+func pubHex(s Signer) string { return hex.EncodeToString(s.PublicKey()) }
+
+// cosignWith returns a finalizer adding s's signature to each block.
+func cosignWith(s Signer) func(b *Block) error {
+	return func(b *Block) error {
+		sig, err := s.Sign(signingMessage(b))
+		if err != nil {
+			return err
+		}
+		b.Signatures = append(b.Signatures, BlockSignature{Pubkey: pubHex(s), Signature: hex.EncodeToString(sig)})
+		return nil
+	}
+}
+
+func testUpdate(ts int64) *PriceUpdate {
+	return &PriceUpdate{Asset: "XAU/USD", Unit: "troy_ounce", PriceE8: 2650_00000000, Scale: 8, Sources: 3, Timestamp: ts}
+}
+
+func mint(t *testing.T, core *AurumCore, payloads ...TxPayload) Block {
+	t.Helper()
+	b, err := core.AppendBlock(payloads...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *b
+}
+
+func TestVerifyBlockSingleSigner(t *testing.T) {
+	leader := newTestSigner(t, 1)
+	src := newTestCore(t, leader)
+	b0 := mint(t, src, testUpdate(1))
+	b1 := mint(t, src, testUpdate(2))
+	trustLeader := func(pub string) bool { return pub == pubHex(leader) }
+
+	tamperedTx := b1
+	tamperedTx.Transactions = append([]Transaction(nil), b1.Transactions...)
+	tamperedTx.Transactions[0].Data = []byte(`{"asset":"XAU/USD","price_e8":1,"type":"price_update","version":1}`)
+	badLink := b1
+	badLink.PreviousHash = genesisPrevHash
+	badSig := b1
+	badSig.Signature = b0.Signature
+
+	tests := []struct {
+		name    string
+		b       Block
+		trusted func(string) bool
+		wantErr string
+	}{
+		{"valid", b1, trustLeader, ""},
+		{"untrusted signer", b1, func(string) bool { return false }, "untrusted signer"},
+		{"tampered transaction", tamperedTx, trustLeader, "hash mismatch"},
+		{"broken link", badLink, trustLeader, "previous hash"},
+		{"wrong signature", badSig, trustLeader, "invalid signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := newTestCore(t, newTestSigner(t, 9))
+			if err := dst.AppendVerified(b0, trustLeader); err != nil {
+				t.Fatalf("genesis block: %v", err)
+			}
+			err := dst.VerifyBlock(&b0, &tt.b, tt.trusted)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyBlockValidatorGenesis(t *testing.T) {
+	leader, peer := newTestSigner(t, 1), newTestSigner(t, 2)
+	vs, err := NewValidatorSet([]string{pubHex(leader), pubHex(peer)}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trustLeader := func(pub string) bool { return pub == pubHex(leader) }
+
+	src := newTestCore(t, leader)
+	b0 := mint(t, src, testUpdate(1))
+	src.SetBootstrapValidators(vs)
+	src.AddFinalizer(cosignWith(peer))
+	b1 := mint(t, src, testUpdate(2))
+	b2 := mint(t, src, testUpdate(3))
+	if validatorGenesisIn(&b1) == nil || validatorGenesisIn(&b2) != nil {
+		t.Fatal("genesis must be recorded exactly once, in the first multi-signer block")
+	}
+
+	dst := newTestCore(t, newTestSigner(t, 9))
+	for _, b := range []Block{b0, b1, b2} {
+		if err := dst.AppendVerified(b, trustLeader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dst.ValidatorSetAt(0) != nil {
+		t.Error("set in force before the genesis block")
+	}
+	if got := dst.ValidatorSetAt(1); got == nil || got.Hash() != vs.Hash() {
+		t.Errorf("set at genesis height = %v, want %v", got, vs)
+	}
+
+	// A reloaded ledger derives the same history without any configuration
+	reloaded := NewAurumCore(dst.filepath, newTestSigner(t, 9))
+	if got := reloaded.ValidatorSetAt(2); got == nil || got.Hash() != vs.Hash() {
+		t.Errorf("reloaded set = %v, want %v", got, vs)
+	}
+
+	unsigned := b1
+	unsigned.Signatures = unsigned.Signatures[:1]
+	fresh := newTestCore(t, newTestSigner(t, 9))
+	if err := fresh.AppendVerified(b0, trustLeader); err != nil {
+		t.Fatal(err)
+	}
+	if err := fresh.VerifyBlock(&b0, &unsigned, trustLeader); err == nil || !strings.Contains(err.Error(), "validator signatures") {
+		t.Errorf("genesis block without quorum: error = %v", err)
+	}
+	if err := fresh.VerifyBlock(&b0, &b1, func(string) bool { return false }); err == nil {
+		t.Error("genesis block from an untrusted signer accepted")
+	}
+
+	// A second genesis is refused once the ledger has a set
+	src.vmu.Lock()
+	src.validatorEpochs = nil
+	src.vmu.Unlock()
+	again := mint(t, src, testUpdate(4))
+	if err := dst.VerifyBlock(&b2, &again, trustLeader); err == nil || !strings.Contains(err.Error(), "already on the ledger") {
+		t.Errorf("second genesis: error = %v", err)
+	}
+}
+
+func TestAppendBlockReproposal(t *testing.T) {
+	signer := newTestSigner(t, 1)
+	path := filepath.Join(t.TempDir(), "chain.dat")
+	core := NewAurumCore(path, signer)
+	fail := true
+	failing := func(b *Block) error {
+		if fail {
+			return fmt.Errorf("quorum not reached")
+		}
+		return nil
+	}
+	core.AddFinalizer(failing)
+	if _, err := core.AppendBlock(testUpdate(1)); err == nil {
+		t.Fatal("finalizer error ignored")
+	}
+	first := *core.proposal
+
+	// The proposal is re-proposed as is, however old, whatever this round brings
+	core.proposal.Timestamp -= int64(10 * mintInterval / time.Second)
+	first.Timestamp = core.proposal.Timestamp
+	if _, err := core.AppendBlock(testUpdate(2)); err == nil {
+		t.Fatal("finalizer error ignored")
+	}
+	if core.proposal.Hash != first.Hash {
+		t.Fatal("an old proposal was replaced by a fresh block")
+	}
+
+	// It survives a restart, and lands once the finalizers accept it
+	core = NewAurumCore(path, signer)
+	if core.proposal == nil || core.proposal.Hash != first.Hash {
+		t.Fatalf("proposal not restored: %+v", core.proposal)
+	}
+	fail = false
+	core.AddFinalizer(failing)
+	b := mint(t, core, testUpdate(3))
+	if b.Hash != first.Hash {
+		t.Fatal("restored proposal was not re-proposed")
+	}
+	if _, err := os.Stat(path + ".proposal"); !os.IsNotExist(err) {
+		t.Errorf("proposal left behind after it landed: %v", err)
+	}
+	if core = NewAurumCore(path, signer); core.proposal != nil {
+		t.Error("a landed block was restored as a proposal")
+	}
+}
+
+func TestVerifyBlockEncodingCommitted(t *testing.T) {
+	leader := newTestSigner(t, 1)
+	trustLeader := func(pub string) bool { return pub == pubHex(leader) }
+	src := newTestCore(t, leader)
+	b0 := mint(t, src, testUpdate(1))
+	b1 := mint(t, src, testUpdate(2))
+
+	// Dropping the label must not pass, even where both encodings agree
+	relabelled := b1
+	relabelled.Transactions = append([]Transaction(nil), b1.Transactions...)
+	relabelled.Transactions[0].Encoding = ""
+	dst := newTestCore(t, newTestSigner(t, 9))
+	if err := dst.AppendVerified(b0, trustLeader); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.VerifyBlock(&b0, &relabelled, trustLeader); err == nil || !strings.Contains(err.Error(), "merkle root") {
+		t.Errorf("stripped encoding: error = %v", err)
+	}
+	if err := dst.VerifyBlock(&b0, &b1, trustLeader); err != nil {
+		t.Errorf("original block: %v", err)
+	}
+}
+
+func TestVerifyCheckpointByHeight(t *testing.T) {
+	typed, err := newTransaction(testUpdate(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyData := []byte(`{"asset":"XAU/USD","price":2650.5,"sources":3}`)
+	legacy := Transaction{Data: legacyData}
+	checkpoint, err := newTransaction(&Checkpoint{Height: 99, BlockHash: "prev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		from    int64
+		index   int64
+		txs     []Transaction
+		wantErr string
+	}{
+		{"checkpoint present", 0, 100, []Transaction{typed, checkpoint}, ""},
+		{"checkpoint missing", 0, 100, []Transaction{typed}, "expected one checkpoint"},
+		{"version 0 tx does not exempt", 0, 100, []Transaction{legacy}, "expected one checkpoint"},
+		{"below checkpoints_from", 200, 100, []Transaction{legacy}, ""},
+		{"unexpected below checkpoints_from", 200, 100, []Transaction{typed, checkpoint}, "unexpected checkpoint"},
+		{"off interval", 0, 101, []Transaction{typed}, ""},
+		{"from mid interval", 150, 200, []Transaction{typed}, "expected one checkpoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := newTestCore(t, newTestSigner(t, 1))
+			core.SetCheckpointsFrom(tt.from)
+			err := core.verifyCheckpoint(&Block{Index: tt.index, PreviousHash: "prev", Transactions: tt.txs})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"aurum-oracle/pkg/fixed"
+	"aurum-oracle/pkg/units"
+)
+
+// --- OHLC Candles ---
+//
+// Candles are folded from the ledger's PriceUpdate transactions, bucketed
+// by the update's timestamp on UTC boundaries of each interval. They are
+// built from the chain at startup and extended as blocks land, whether
+// minted here or replicated.
+
+var candleIntervals = map[string]int64{"1m": 60, "5m": 300, "1h": 3600, "1d": 86400}
+
+const (
+	defaultCandles = 500  // returned when from is omitted
+	maxCandles     = 5000 // per response; page on with next_from
+)
+
+// Candle is one interval bar. Open and close are the first and last
+// updates in block order; FirstBlock..LastBlock produced it.
+type Candle struct {
+	Start      int64       `json:"start"`
+	End        int64       `json:"end"` // exclusive
+	Open       fixed.Price `json:"open_e8"`
+	High       fixed.Price `json:"high_e8"`
+	Low        fixed.Price `json:"low_e8"`
+	Close      fixed.Price `json:"close_e8"`
+	Updates    int         `json:"updates"`
+	SourcesMin int         `json:"sources_min"`
+	SourcesMax int         `json:"sources_max"`
+	SpreadBps  *float64    `json:"spread_bps,omitempty"` // mean over updates that recorded one, to 0.0001
+	FirstBlock int64       `json:"first_block"`
+	LastBlock  int64       `json:"last_block"`
+
+	spreadSum float64
+	spreadN   int
+}
+
+func (c *Candle) add(index int64, pu *PriceUpdate) {
+	p := pu.PriceE8
+	if c.Updates == 0 {
+		c.Open, c.High, c.Low = p, p, p
+		c.SourcesMin, c.SourcesMax = pu.Sources, pu.Sources
+		c.FirstBlock = index
+	}
+	c.High, c.Low = max(c.High, p), min(c.Low, p)
+	c.SourcesMin, c.SourcesMax = min(c.SourcesMin, pu.Sources), max(c.SourcesMax, pu.Sources)
+	c.Close, c.LastBlock = p, index
+	c.Updates++
+	if pu.SpreadBps != nil {
+		c.spreadSum += pu.SpreadBps.Float()
+		c.spreadN++
+		mean := math.Round(c.spreadSum/float64(c.spreadN)*1e4) / 1e4
+		c.SpreadBps = &mean
+	}
+}
+
+// candleSeries holds one interval's candles, ordered by start.
+type candleSeries struct {
+	seconds int64
+	starts  []int64
+	byStart map[int64]*Candle
+}
+
+func (s *candleSeries) add(ts, index int64, pu *PriceUpdate) {
+	start := ts - ((ts%s.seconds)+s.seconds)%s.seconds
+	c, ok := s.byStart[start]
+	if !ok {
+		c = &Candle{Start: start, End: start + s.seconds}
+		s.byStart[start] = c
+		// Blocks arrive in time order, so this is nearly always an append
+		i := sort.Search(len(s.starts), func(i int) bool { return s.starts[i] >= start })
+		s.starts = append(s.starts, 0)
+		copy(s.starts[i+1:], s.starts[i:])
+		s.starts[i] = start
+	}
+	c.add(index, pu)
+}
+
+var candleIndex = struct {
+	sync.RWMutex
+	next   int64 // first block not yet folded in
+	series map[string]*candleSeries
+}{series: map[string]*candleSeries{}}
+
+func init() {
+	for name, secs := range candleIntervals {
+		candleIndex.series[name] = &candleSeries{seconds: secs, byStart: map[int64]*Candle{}}
+	}
+}
+
+// runCandleIndexer folds every block into the candle series, then each
+// new one as it is appended.
+func runCandleIndexer(ctx context.Context) {
+	for {
+		// Grab the wait channel first so a block landing meanwhile is not missed
+		wait := core.WaitForBlock()
+		for {
+			candleIndex.RLock()
+			next := candleIndex.next
+			candleIndex.RUnlock()
+			batch := core.BlocksFrom(next, maxBlocksPerPage)
+			if len(batch) == 0 {
+				break
+			}
+			foldCandles(batch)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-wait:
+		}
+	}
+}
+
+func foldCandles(blocks []Block) {
+	candleIndex.Lock()
+	defer candleIndex.Unlock()
+	for _, b := range blocks {
+		candleIndex.next = b.Index + 1
+		pu, ok := priceUpdateIn(b)
+		if !ok || pu.PriceE8 <= 0 {
+			continue
+		}
+		ts := pu.Timestamp
+		if ts == 0 {
+			ts = b.Timestamp
+		}
+		for _, s := range candleIndex.series {
+			s.add(ts, b.Index, pu)
+		}
+	}
+}
+
+// candleJSON adds the exact decimals to a candle for display.
+type candleJSON struct {
+	*Candle
+	OpenDec  json.Number `json:"open"`
+	HighDec  json.Number `json:"high"`
+	LowDec   json.Number `json:"low"`
+	CloseDec json.Number `json:"close"`
+}
+
+// handleCandles serves /candles?interval=1h&from=&to= (unix seconds). Each
+// candle overlapping [from, to] is returned, up to maxCandles; next_from
+// continues a truncated range. delayed=true (the gateway's free tier) only
+// returns candles that closed at least 15 minutes ago.
+func handleCandles(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	name := q.Get("interval")
+	if name == "" {
+		name = "1h"
+	}
+	secs, ok := candleIntervals[name]
+	if !ok {
+		http.Error(w, "unknown interval "+strconv.Quote(name)+" (use 1m, 5m, 1h or 1d)", http.StatusBadRequest)
+		return
+	}
+	now := time.Now().Unix()
+	to := now
+	if v := q.Get("to"); v != "" {
+		t, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid to", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+	from := to - defaultCandles*secs
+	if v := q.Get("from"); v != "" {
+		f, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid from", http.StatusBadRequest)
+			return
+		}
+		from = f
+	}
+	if from > to {
+		http.Error(w, "from is after to", http.StatusBadRequest)
+		return
+	}
+	delayed := q.Get("delayed") == "true"
+	cutoff := now - 15*60
+
+	candleIndex.RLock()
+	s := candleIndex.series[name]
+	out := []candleJSON{}
+	var nextFrom int64
+	i := sort.Search(len(s.starts), func(i int) bool { return s.starts[i]+secs > from })
+	for ; i < len(s.starts) && s.starts[i] <= to; i++ {
+		c := s.byStart[s.starts[i]]
+		if delayed && c.End > cutoff {
+			break
+		}
+		if len(out) == maxCandles {
+			nextFrom = c.Start
+			break
+		}
+		cp := *c
+		out = append(out, candleJSON{
+			Candle:   &cp,
+			OpenDec:  json.Number(c.Open.String()),
+			HighDec:  json.Number(c.High.String()),
+			LowDec:   json.Number(c.Low.String()),
+			CloseDec: json.Number(c.Close.String()),
+		})
+	}
+	indexed := candleIndex.next
+	candleIndex.RUnlock()
+
+	resp := map[string]interface{}{
+		"asset":          "XAU/USD",
+		"unit":           units.TroyOunce.Name,
+		"scale":          fixed.Scale,
+		"interval":       name,
+		"seconds":        secs,
+		"from":           from,
+		"to":             to,
+		"candles":        out,
+		"indexed_height": indexed,
+		"delayed_15m":    delayed,
+	}
+	if nextFrom != 0 {
+		resp["next_from"] = nextFrom
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"os"
+
+	"aurum-oracle/pkg/canonjson"
+	"aurum-oracle/pkg/keystore"
+)
+
+// runCommand dispatches `aurum-aggregator <command> [flags]` and returns the exit code.
+func runCommand(name string, args []string) int {
+	switch name {
+	case "keygen":
+		return cmdKeygen(args)
+	case "approve-validator-change":
+		return cmdApproveValidatorChange(args)
+	case "check-hash-vectors":
+		return cmdCheckHashVectors(args)
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\ncommands:\n  keygen                     create (or import) this node's encrypted signing key\n  approve-validator-change   sign a validator set change with this node's key\n  check-hash-vectors         check the transaction hash encoding against its test vectors\n", name)
+	return 2
+}
+
+// cmdApproveValidatorChange prints this validator's approval for a change.
+// Operators collect a threshold of approvals into the change's "approvals"
+// list and POST it to the leader's /validators endpoint.
+func cmdApproveValidatorChange(args []string) int {
+	fs := flag.NewFlagSet("approve-validator-change", flag.ContinueOnError)
+	op := fs.String("op", "", "add, remove or rotate")
+	key := fs.String("key", "", "hex ed25519 key being added, removed or rotated out")
+	newKey := fs.String("new-key", "", "replacement key (rotate only)")
+	threshold := fs.Int("threshold", 0, "new threshold (0 keeps the current one)")
+	setHash := fs.String("set-hash", "", "set_hash of the current set, from GET /validators")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *op == "" || *key == "" || *setHash == "" {
+		fmt.Fprintln(os.Stderr, "-op, -key and -set-hash are required")
+		return 2
+	}
+
+	loadConfig()
+	signer, err := loadSigner()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "signing key: %v\n", err)
+		return 1
+	}
+	change := ValidatorChange{Op: *op, Key: *key, NewKey: *newKey, Threshold: *threshold, SetHash: *setHash}
+	sig, err := signer.Sign(change.ApprovalMessage())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sign approval: %v\n", err)
+		if config.Signer.Mode == "remote" {
+			fmt.Fprintf(os.Stderr, "a remote signer only signs approvals listed in its SIGNER_APPROVALS_FILE:\n%s\n", change.ApprovalMessage())
+		}
+		return 1
+	}
+	approval := BlockSignature{
+		Pubkey:    hex.EncodeToString(signer.PublicKey()),
+		Signature: hex.EncodeToString(sig),
+	}
+	change.Approvals = []BlockSignature{approval}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(change)
+	return 0
+}
+
+// cmdCheckHashVectors runs the RFC 8785 vectors that transaction hashes are
+// specified by, so a build can be checked against what third-party
+// verifiers implement.
+func cmdCheckHashVectors(args []string) int {
+	fs := flag.NewFlagSet("check-hash-vectors", flag.ContinueOnError)
+	path := fs.String("file", "pkg/canonjson/vectors.json", "test vector file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	raw, err := os.ReadFile(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read vectors: %v\n", err)
+		return 1
+	}
+	var file struct {
+		Vectors []struct {
+			Name      string `json:"name"`
+			Input     string `json:"input"`
+			Canonical string `json:"canonical"`
+			SHA256    string `json:"sha256"`
+			Error     bool   `json:"error"`
+		} `json:"vectors"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		fmt.Fprintf(os.Stderr, "parse vectors: %v\n", err)
+		return 1
+	}
+	failed := 0
+	for _, v := range file.Vectors {
+		got, err := canonjson.Canonicalize([]byte(v.Input))
+		sum := sha256.Sum256(got)
+		switch {
+		case v.Error && err == nil:
+			fmt.Printf("FAIL %s: accepted, want an error\n", v.Name)
+		case v.Error:
+			fmt.Printf("ok   %s (refused: %v)\n", v.Name, err)
+			continue
+		case err != nil:
+			fmt.Printf("FAIL %s: %v\n", v.Name, err)
+		case string(got) != v.Canonical || hex.EncodeToString(sum[:]) != v.SHA256:
+			fmt.Printf("FAIL %s: got %s\n", v.Name, got)
+		default:
+			fmt.Printf("ok   %s\n", v.Name)
+			continue
+		}
+		failed++
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d vectors failed\n", failed, len(file.Vectors))
+		return 1
+	}
+	return 0
+}
+
+// cmdKeygen writes a new passphrase-encrypted keystore to key_path (or -out).
+// With -import it re-seals an existing unencrypted PEM key instead, so the
+// node keeps its identity. It never overwrites an existing file.
+func cmdKeygen(args []string) int {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	out := fs.String("out", "", "keystore path (default: key_path from aurum_config.json)")
+	importPath := fs.String("import", "", "unencrypted PKCS#8 PEM key to migrate instead of generating one")
+	plaintext := fs.Bool("insecure-plaintext", false, "write an unencrypted PEM key (development only)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *out == "" {
+		loadConfig()
+		*out = config.KeyPath
+	}
+	if *out == "" {
+		fmt.Fprintln(os.Stderr, "no -out given and key_path is not set")
+		return 2
+	}
+
+	var key ed25519.PrivateKey
+	if *importPath != "" {
+		data, err := os.ReadFile(*importPath)
+		if err == nil {
+			key, err = keystore.ParsePEM(data)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "import %s: %v\n", *importPath, err)
+			return 1
+		}
+	} else {
+		var err error
+		if _, key, err = ed25519.GenerateKey(nil); err != nil {
+			fmt.Fprintf(os.Stderr, "generate key: %v\n", err)
+			return 1
+		}
+	}
+
+	var data []byte
+	if *plaintext {
+		pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+		data = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})
+	} else {
+		passphrase, err := keystore.Passphrase()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(passphrase) < 12 {
+			fmt.Fprintln(os.Stderr, "set AURUM_KEY_PASSPHRASE or AURUM_KEY_PASSPHRASE_FILE to a passphrase of at least 12 characters")
+			return 2
+		}
+		if data, err = keystore.Seal(key, passphrase); err != nil {
+			fmt.Fprintf(os.Stderr, "encrypt key: %v\n", err)
+			return 1
+		}
+	}
+
+	if err := keystore.WriteFile(*out, data); err != nil {
+		if os.IsExist(err) {
+			fmt.Fprintf(os.Stderr, "%s already exists; refusing to overwrite a node identity\n", *out)
+		} else {
+			fmt.Fprintf(os.Stderr, "write %s: %v\n", *out, err)
+		}
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s\n", *out)
+	fmt.Println(hex.EncodeToString(key.Public().(ed25519.PublicKey)))
+	return 0
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -23,8 +26,8 @@ func NewCosmosAnchor(config Config) *CosmosAnchor {
 	}
 }
 
-// Anchor sends the Merkle Root to the Cosmos chain
-// In a real production env, this would sign a MsgStoreCode or MsgMemo transaction
+// Anchor sends the Merkle Root to the Cosmos chain. The receipt goes on the
+// ledger, with the Cosmos transaction hash, only once the chain accepted it.
 func (ca *CosmosAnchor) Anchor(blockIndex int64, merkleRoot string, blockHash string) {
 	if !ca.Enabled {
 		return
@@ -42,24 +45,92 @@ func (ca *CosmosAnchor) Anchor(blockIndex int64, merkleRoot string, blockHash st
 	}
 	
 	jsonData, _ := json.Marshal(payload)
-
-	// In a demo environment, we often don't have a full Cosmos node running locally.
-	// We will log the exact transaction structure that WOULD be sent.
 	log.Printf("⚓ COSMOS ANCHOR | Broadcasting Tx to %s (%s)", ca.ChainID, ca.Endpoint)
 	log.Printf("   └── Payload: %s", string(jsonData))
 
-	// Mock HTTP Call to demonstrate integration
-	// go ca.broadcast(jsonData) 
+	txHash, err := ca.broadcast(jsonData)
+	if err != nil {
+		log.Printf("⚠️  Anchor of block #%d failed: %v", blockIndex, err)
+		return
+	}
+	log.Printf("✅ Anchor of block #%d confirmed on %s: tx %s", blockIndex, ca.ChainID, txHash)
+
+	// The receipt goes on the ledger with the next block
+	sum := sha256.Sum256(jsonData)
+	queueAnchorReceipt(&AnchorReceipt{
+		ChainID:     ca.ChainID,
+		Height:      blockIndex,
+		BlockHash:   blockHash,
+		MerkleRoot:  merkleRoot,
+		PayloadHash: hex.EncodeToString(sum[:]),
+		TxHash:      txHash,
+		AnchoredAt:  time.Now().Unix(),
+	})
 }
 
-func (ca *CosmosAnchor) broadcast(data []byte) {
+// pendingAnchors are receipts waiting for the next minted block.
+var pendingAnchors = struct {
+	sync.Mutex
+	list []*AnchorReceipt
+}{}
+
+func queueAnchorReceipt(r *AnchorReceipt) {
+	pendingAnchors.Lock()
+	defer pendingAnchors.Unlock()
+	pendingAnchors.list = append(pendingAnchors.list, r)
+}
+
+func takeAnchorReceipts() []*AnchorReceipt {
+	pendingAnchors.Lock()
+	defer pendingAnchors.Unlock()
+	list := pendingAnchors.list
+	pendingAnchors.list = nil
+	return list
+}
+
+// requeueAnchorReceipts puts receipts back after a failed mint.
+func requeueAnchorReceipts(list []*AnchorReceipt) {
+	pendingAnchors.Lock()
+	defer pendingAnchors.Unlock()
+	pendingAnchors.list = append(list, pendingAnchors.list...)
+}
+
+// broadcast posts the anchor transaction and returns its Cosmos hash. A
+// transaction the chain did not accept (non-zero code) is an error.
+func (ca *CosmosAnchor) broadcast(data []byte) (string, error) {
 	client := &http.Client{Timeout: 5 * time.Second}
 	// Assuming a custom sidecar listening on port 1317
 	resp, err := client.Post(ca.Endpoint+"/txs", "application/json", bytes.NewBuffer(data))
 	if err != nil {
-		log.Printf("⚠️  Anchor failed: %v", err)
-		return
+		return "", err
 	}
 	defer resp.Body.Close()
-	log.Printf("✅ Anchor confirmed on Cosmos Testnet")
-}
\ No newline at end of file
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+	// Legacy REST answers with the result at the top level, the gRPC
+	// gateway under tx_response
+	type txResult struct {
+		TxHash string `json:"txhash"`
+		Code   uint32 `json:"code"`
+		RawLog string `json:"raw_log"`
+	}
+	var body struct {
+		txResult
+		TxResponse *txResult `json:"tx_response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("bad response: %w", err)
+	}
+	result := body.txResult
+	if body.TxResponse != nil {
+		result = *body.TxResponse
+	}
+	if result.Code != 0 {
+		return "", fmt.Errorf("rejected with code %d: %s", result.Code, result.RawLog)
+	}
+	if result.TxHash == "" {
+		return "", fmt.Errorf("response carries no txhash")
+	}
+	return result.TxHash, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnchorRecordsReceiptOnlyAfterBroadcast(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantTxHash string // "" for no receipt
+	}{
+		{"accepted", http.StatusOK, `{"height":"12","txhash":"AB12"}`, "AB12"},
+		{"accepted via tx_response", http.StatusOK, `{"tx_response":{"txhash":"CD34","code":0}}`, "CD34"},
+		{"rejected by the chain", http.StatusOK, `{"tx_response":{"txhash":"EF56","code":5,"raw_log":"insufficient funds"}}`, ""},
+		{"no tx hash", http.StatusOK, `{}`, ""},
+		{"endpoint error", http.StatusBadGateway, ``, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/txs" || r.Method != http.MethodPost {
+					t.Errorf("%s %s", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			takeAnchorReceipts()
+			t.Cleanup(func() { takeAnchorReceipts() })
+
+			ca := &CosmosAnchor{Endpoint: srv.URL, ChainID: "testchain-1", Enabled: true}
+			ca.Anchor(5, "root", "hash")
+			receipts := takeAnchorReceipts()
+			if tt.wantTxHash == "" {
+				if len(receipts) != 0 {
+					t.Fatalf("receipt recorded for a failed anchor: %+v", receipts[0])
+				}
+				return
+			}
+			if len(receipts) != 1 || receipts[0].TxHash != tt.wantTxHash || receipts[0].Height != 5 || receipts[0].ChainID != "testchain-1" {
+				t.Fatalf("receipts = %+v", receipts)
+			}
+		})
+	}
+
+	// Nothing is sent or recorded while anchoring is disabled
+	(&CosmosAnchor{Endpoint: "http://127.0.0.1:0"}).Anchor(5, "root", "hash")
+	if receipts := takeAnchorReceipts(); len(receipts) != 0 {
+		t.Errorf("disabled anchor recorded %d receipts", len(receipts))
+	}
+}
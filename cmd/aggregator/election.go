@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const defaultLeaseTTL = 15 * time.Second
+
+var errFenced = errors.New("fenced: leadership lost")
+
+// Lease is the leadership record kept in the shared store. Term is the
+// fencing token: it increases every time the lease changes hands.
+type Lease struct {
+	Holder    string `json:"holder"`
+	Address   string `json:"address"`
+	Term      int64  `json:"term"`
+	ExpiresAt int64  `json:"expires_at"` // unix millis
+}
+
+func (l Lease) expired(now time.Time) bool {
+	return l.Holder == "" || now.UnixMilli() >= l.ExpiresAt
+}
+
+// leaseState is everything the shared store holds. LastBlock is the last
+// block any leader committed, so a successor knows exactly where the chain
+// ends even if the previous leader died before anyone replicated it.
+type leaseState struct {
+	Lease     Lease  `json:"lease"`
+	LastBlock *Block `json:"last_block,omitempty"`
+}
+
+// LeaseStore is the shared, linearizable store the aggregators elect through.
+type LeaseStore interface {
+	// TryAcquire takes the lease if it is free or expired, or renews it if
+	// holder already owns it. It returns the lease as it stands afterwards.
+	TryAcquire(holder, address string, ttl time.Duration) (Lease, error)
+	// Commit records b as the new chain tip, but only while holder still
+	// owns the lease at term and b extends the previously committed tip.
+	Commit(holder string, term int64, b Block) error
+	Release(holder string) error
+	Read() (Lease, *Block, error)
+}
+
+// --- File Store (shared volume: NFS, EFS, Filestore...) ---
+
+type FileLeaseStore struct {
+	path string
+}
+
+func NewFileLeaseStore(path string) *FileLeaseStore {
+	return &FileLeaseStore{path: path}
+}
+
+// withLock runs fn under an exclusive flock, writing back the state if fn changed it.
+func (s *FileLeaseStore) withLock(fn func(st *leaseState) (bool, error)) error {
+	lock, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+
+	var st leaseState
+	if data, err := os.ReadFile(s.path); err == nil {
+		if err := json.Unmarshal(data, &st); err != nil {
+			return fmt.Errorf("corrupt lease store: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	changed, err := fn(&st)
+	if err != nil || !changed {
+		return err
+	}
+
+	data, _ := json.Marshal(st)
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".lease-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *FileLeaseStore) TryAcquire(holder, address string, ttl time.Duration) (Lease, error) {
+	var out Lease
+	err := s.withLock(func(st *leaseState) (bool, error) {
+		now := time.Now()
+		switch {
+		case st.Lease.Holder == holder && !st.Lease.expired(now):
+			st.Lease.ExpiresAt = now.Add(ttl).UnixMilli()
+			st.Lease.Address = address
+		case st.Lease.expired(now):
+			st.Lease = Lease{Holder: holder, Address: address, Term: st.Lease.Term + 1, ExpiresAt: now.Add(ttl).UnixMilli()}
+		default:
+			out = st.Lease
+			return false, nil
+		}
+		out = st.Lease
+		return true, nil
+	})
+	return out, err
+}
+
+func (s *FileLeaseStore) Commit(holder string, term int64, b Block) error {
+	return s.withLock(func(st *leaseState) (bool, error) {
+		if st.Lease.Holder != holder || st.Lease.Term != term || st.Lease.expired(time.Now()) {
+			return false, errFenced
+		}
+		if st.LastBlock != nil && st.LastBlock.Hash == b.Hash {
+			// A retry of a block committed before its local persist failed
+			return false, nil
+		}
+		if st.LastBlock != nil && (b.Index != st.LastBlock.Index+1 || b.PreviousHash != st.LastBlock.Hash) {
+			return false, fmt.Errorf("block %d does not extend committed tip %d", b.Index, st.LastBlock.Index)
+		}
+		st.LastBlock = &b
+		return true, nil
+	})
+}
+
+func (s *FileLeaseStore) Release(holder string) error {
+	return s.withLock(func(st *leaseState) (bool, error) {
+		if st.Lease.Holder != holder {
+			return false, nil
+		}
+		st.Lease.ExpiresAt = 0
+		return true, nil
+	})
+}
+
+func (s *FileLeaseStore) Read() (Lease, *Block, error) {
+	var lease Lease
+	var last *Block
+	err := s.withLock(func(st *leaseState) (bool, error) {
+		lease, last = st.Lease, st.LastBlock
+		return false, nil
+	})
+	return lease, last, err
+}
+
+// --- Elector ---
+
+// Elector campaigns for the lease and switches this node between minting
+// (leader) and replicating (follower).
+type Elector struct {
+	store      LeaseStore
+	nodeID     string
+	address    string
+	ttl        time.Duration
+	replicator *Replicator
+
+	mu     sync.RWMutex
+	term   int64
+	leader Lease
+}
+
+func NewElector(cfg Config, replicator *Replicator) (*Elector, error) {
+	ec := cfg.Election
+	if ec.NodeID == "" || ec.AdvertiseURL == "" || ec.LeasePath == "" {
+		return nil, fmt.Errorf("election requires node_id, advertise_url and lease_path")
+	}
+	if len(cfg.TrustedSigners) == 0 && len(cfg.Validators.Keys) == 0 {
+		// Every aggregator signs with its own key; TOFU would reject the next leader
+		return nil, fmt.Errorf("election requires trusted_signers or validators listing every aggregator key")
+	}
+	ttl := defaultLeaseTTL
+	if ec.LeaseTTLSeconds > 0 {
+		ttl = time.Duration(ec.LeaseTTLSeconds) * time.Second
+	}
+	e := &Elector{
+		store:      NewFileLeaseStore(ec.LeasePath),
+		nodeID:     ec.NodeID,
+		address:    ec.AdvertiseURL,
+		ttl:        ttl,
+		replicator: replicator,
+	}
+	// Followers chase whoever currently holds the lease, then the static peers
+	replicator.peers = func() []string {
+		peers := []string{}
+		if addr := e.leaderAddress(); addr != "" && addr != e.address {
+			peers = append(peers, addr)
+		}
+		return append(peers, cfg.ReplicationPeers...)
+	}
+	return e, nil
+}
+
+func (e *Elector) isLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.term != 0
+}
+
+func (e *Elector) leaderAddress() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leader.Address
+}
+
+// Fence is installed as an AurumCore finalizer: a block only reaches disk once the
+// shared store has accepted it under our current term.
+func (e *Elector) Fence(b *Block) error {
+	e.mu.RLock()
+	term := e.term
+	e.mu.RUnlock()
+	if term == 0 {
+		return errFenced
+	}
+	return e.store.Commit(e.nodeID, term, *b)
+}
+
+// Run campaigns until ctx is cancelled.
+func (e *Elector) Run(ctx context.Context) {
+	var roleCancel context.CancelFunc
+	leading := false
+	startRole := func(fn func(context.Context)) {
+		if roleCancel != nil {
+			roleCancel()
+		}
+		var roleCtx context.Context
+		roleCtx, roleCancel = context.WithCancel(ctx)
+		go fn(roleCtx)
+	}
+	startRole(e.replicator.Run)
+
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	for {
+		lease, err := e.store.TryAcquire(e.nodeID, e.address, e.ttl)
+		switch {
+		case err != nil:
+			log.Printf("⚠️  Election: lease store error: %v", err)
+			// Without the store we cannot prove leadership; stop minting
+			if leading {
+				e.stepDown()
+				leading = false
+				startRole(e.replicator.Run)
+			}
+		case lease.Holder == e.nodeID && !leading:
+			log.Printf("👑 Election: %s acquired leadership (term %d)", e.nodeID, lease.Term)
+			e.setLease(lease, lease.Term)
+			leading = true
+			startRole(e.lead)
+		case lease.Holder == e.nodeID:
+			e.setLease(lease, lease.Term)
+		default:
+			if leading {
+				log.Printf("🔻 Election: lost leadership to %s (term %d)", lease.Holder, lease.Term)
+				e.stepDown()
+				leading = false
+				startRole(e.replicator.Run)
+			}
+			e.setLease(lease, 0)
+		}
+
+		select {
+		case <-ctx.Done():
+			if roleCancel != nil {
+				roleCancel()
+			}
+			if leading {
+				e.store.Release(e.nodeID)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) setLease(lease Lease, term int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.leader = lease
+	e.term = term
+}
+
+func (e *Elector) stepDown() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.term = 0
+}
+
+// lead catches up to the committed tip, then mints until ctx is cancelled.
+func (e *Elector) lead(ctx context.Context) {
+	for {
+		err := e.catchUp(ctx)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("⏳ Election: cannot mint yet, catching up: %v", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(replicationRetryMin):
+		}
+	}
+	runMiningTicker(ctx)
+}
+
+// catchUp brings the local ledger up to the block last committed in the store.
+func (e *Elector) catchUp(ctx context.Context) error {
+	_, last, err := e.store.Read()
+	if err != nil || last == nil {
+		return err
+	}
+	for core.Height() < last.Index {
+		if err := e.replicator.fetchRange(ctx, last.Index); err != nil {
+			return err
+		}
+	}
+	if core.Height() == last.Index {
+		// The previous leader committed it but may have died before serving it
+		if err := core.AppendVerified(*last, e.replicator.isTrusted); err != nil {
+			return err
+		}
+		updateLiveCache(*last)
+	}
+	tip := core.GetLatest()
+	if tip.Index != last.Index || tip.Hash != last.Hash {
+		return fmt.Errorf("local tip %d/%s diverges from committed tip %d/%s", tip.Index, tip.Hash, last.Index, last.Hash)
+	}
+	return nil
+}
+
+// electionStatus is merged into /chain so operators can see who leads.
+func (e *Elector) status() map[string]interface{} {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return map[string]interface{}{
+		"node_id":     e.nodeID,
+		"leader":      e.leader.Holder,
+		"leader_url":  e.leader.Address,
+		"term":        e.leader.Term,
+		"is_leader":   e.term != 0,
+		"lease_until": e.leader.ExpiresAt,
+	}
+}
+
+// fetchRange pulls blocks below `until` from the first peer that has them.
+func (rep *Replicator) fetchRange(ctx context.Context, until int64) error {
+	var lastErr error = fmt.Errorf("no peers")
+	for _, peer := range rep.peers() {
+		url := fmt.Sprintf("%s/blocks?from=%d&limit=%d", strings.TrimSuffix(peer, "/"), core.Height(), maxBlocksPerPage)
+		reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
+		resp, err := rep.client.Do(req)
+		if err != nil {
+			cancel()
+			lastErr = err
+			continue
+		}
+		var page struct {
+			Blocks []Block `json:"blocks"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		cancel()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		progressed := false
+		for _, b := range page.Blocks {
+			if b.Index >= until {
+				break
+			}
+			if b.Index < core.Height() {
+				continue
+			}
+			if err := core.AppendVerified(b, rep.isTrusted); err != nil {
+				return err
+			}
+			updateLiveCache(b)
+			progressed = true
+		}
+		if progressed {
+			return nil
+		}
+		lastErr = fmt.Errorf("%s has nothing past height %d", peer, core.Height())
+	}
+	return lastErr
+}
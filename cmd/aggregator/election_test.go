@@ -0,0 +1,29 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFenceCommitIdempotent(t *testing.T) {
+	store := NewFileLeaseStore(filepath.Join(t.TempDir(), "lease.json"))
+	lease, err := store.TryAcquire("node-a", "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core := newTestCore(t, newTestSigner(t, 1))
+	b0 := mint(t, core, testUpdate(1))
+	if err := store.Commit("node-a", lease.Term, b0); err != nil {
+		t.Fatal(err)
+	}
+	// Persisting failed after the commit: the same block is re-proposed
+	if err := store.Commit("node-a", lease.Term, b0); err != nil {
+		t.Fatalf("re-committing the committed block: %v", err)
+	}
+	other := b0
+	other.Hash = "different"
+	if err := store.Commit("node-a", lease.Term, other); err == nil {
+		t.Fatal("a different block at the committed height was accepted")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+// fxMethod describes the derivation recorded in each block, so anyone can
+// recompute it from the inputs alongside it.
+const fxMethod = "fixed point, scale 1e8; rates_e8[CCY] = median over inputs of node rate (CCY per USD), even counts averaging the middle two; derived_e8[XAU/CCY] = price_e8 * rates_e8[CCY] / 1e8; rounding half away from zero"
+
+// fxInput is one contributing node's FX rates, CCY per USD, and the age of
+// the oldest rate behind them when the aggregator received them.
+type fxInput struct {
+	Node       string                 `json:"node"`
+	Rates      map[string]fixed.Price `json:"rates_e8"`
+	AgeSeconds int64                  `json:"age_seconds"`
+}
+
+// FXDerivation is the "fx" field of a price transaction.
+type FXDerivation struct {
+	Base    string                 `json:"base"`
+	Method  string                 `json:"method"`
+	Rates   map[string]fixed.Price `json:"rates_e8"`
+	Derived map[string]fixed.Price `json:"derived_e8"`
+	Inputs  []fxInput              `json:"inputs"`
+	// OldestAgeSeconds is the oldest input's age (0 in blocks predating it)
+	OldestAgeSeconds int64 `json:"oldest_age_seconds"`
+}
+
+// deriveFX takes the median of each currency over the nodes that reported
+// it and converts the block's XAU/USD price. Nil when no node had rates.
+func deriveFX(price fixed.Price, inputs []fxInput) *FXDerivation {
+	byCurrency := map[string][]fixed.Price{}
+	for _, in := range inputs {
+		for c, r := range in.Rates {
+			if r > 0 {
+				byCurrency[c] = append(byCurrency[c], r)
+			}
+		}
+	}
+	if len(byCurrency) == 0 {
+		return nil
+	}
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].Node < inputs[j].Node })
+	d := &FXDerivation{Base: "USD", Method: fxMethod, Rates: map[string]fixed.Price{}, Derived: map[string]fixed.Price{}, Inputs: inputs}
+	for _, in := range inputs {
+		d.OldestAgeSeconds = max(d.OldestAgeSeconds, in.AgeSeconds)
+	}
+	for c, values := range byCurrency {
+		rate := fixed.Median(values)
+		derived, err := fixed.Mul(price, rate)
+		if err != nil {
+			log.Printf("⚠️  FX: skipping %s: %v", c, err)
+			continue
+		}
+		d.Rates[c], d.Derived["XAU/"+c] = rate, derived
+	}
+	if len(d.Rates) == 0 {
+		return nil
+	}
+	return d
+}
+
+// decodeLegacyFX reads the "fx" field of an untyped price transaction;
+// the first ones carried float rates.
+func decodeLegacyFX(raw json.RawMessage) *FXDerivation {
+	var d struct {
+		FXDerivation
+		LegacyRates   map[string]float64 `json:"rates"`
+		LegacyDerived map[string]float64 `json:"derived"`
+	}
+	if err := json.Unmarshal(raw, &d); err != nil || d.Base != "USD" {
+		return nil
+	}
+	if d.Rates == nil && d.LegacyRates != nil {
+		d.Rates, d.Derived = map[string]fixed.Price{}, map[string]fixed.Price{}
+		for c, r := range d.LegacyRates {
+			d.Rates[c], _ = fixed.FromFloat(r)
+		}
+		for pair, p := range d.LegacyDerived {
+			d.Derived[pair], _ = fixed.FromFloat(p)
+		}
+	}
+	return &d.FXDerivation
+}
+
+// priceInQuote converts via the block's recorded derivation; quote is an
+// ISO code such as "EUR".
+func priceInQuote(b Block, quote string) (fixed.Price, fixed.Price, error) {
+	pu, ok := priceUpdateIn(b)
+	if !ok || pu.FX == nil {
+		return 0, 0, fmt.Errorf("block #%d has no FX rates", b.Index)
+	}
+	d := pu.FX
+	quote = strings.ToUpper(quote)
+	price, ok := d.Derived["XAU/"+quote]
+	if !ok {
+		return 0, 0, fmt.Errorf("no %s rate in block #%d", quote, b.Index)
+	}
+	return price, d.Rates[quote], nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+func TestDeriveFX(t *testing.T) {
+	price := fixed.Price(2000 * fixed.One)
+	d := deriveFX(price, []fxInput{
+		{Node: "b", Rates: map[string]fixed.Price{"EUR": 92_000000, "JPY": 150 * fixed.One}, AgeSeconds: 3600},
+		{Node: "a", Rates: map[string]fixed.Price{"EUR": 90_000000}, AgeSeconds: 60},
+	})
+	if d == nil {
+		t.Fatal("no derivation")
+	}
+	if d.OldestAgeSeconds != 3600 {
+		t.Errorf("oldest age %d, want 3600", d.OldestAgeSeconds)
+	}
+	if d.Inputs[0].Node != "a" {
+		t.Errorf("inputs not sorted by node: %v", d.Inputs)
+	}
+	want := map[string]fixed.Price{"XAU/EUR": 1820 * fixed.One, "XAU/JPY": 300000 * fixed.One}
+	for pair, p := range want {
+		if d.Derived[pair] != p {
+			t.Errorf("%s = %s, want %s", pair, d.Derived[pair], p)
+		}
+	}
+	if deriveFX(price, nil) != nil {
+		t.Error("derivation without inputs")
+	}
+}
@@ -1,24 +1,35 @@
 package main
 
 import (
-	"crypto/ed25519"
-	"crypto/x509"
+	"context"
 	"encoding/hex"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
-	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"aurum-oracle/pkg/calibration"
+	"aurum-oracle/pkg/fixed"
+	"aurum-oracle/pkg/listener"
+	"aurum-oracle/pkg/units"
 )
 
 // --- Config ---
+
+// TLSClientConfig points at the CA / client certificate used when dialing peers.
+type TLSClientConfig struct {
+	CAFile   string `json:"ca_file"`
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+}
+
 type Config struct {
-	NodeType        string   `json:"node_type"`
+	NodeType        string   `json:"node_type"` // "aggregator" (leader) or "follower"
 	ServerPort      string   `json:"server_port"`
 	StoragePath     string   `json:"storage_path"`
 	KeyPath         string   `json:"key_path"`
@@ -29,128 +40,374 @@ type Config struct {
 		ChainID     string `json:"chain_id"`
 		RPCEndpoint string `json:"rpc_endpoint"`
 	} `json:"cosmos"`
+	// Listener overrides server_port; addr defaults to ":"+server_port
+	Listener listener.Config `json:"listener"`
+	// Followers stream from replication_peers and only accept blocks signed
+	// by trusted_signers (hex ed25519); follower mode requires them unless
+	// validators are configured
+	TrustedSigners []string        `json:"trusted_signers"`
+	// CalibrationSigners are the hex keys allowed to sign node calibrations;
+	// with none, nodes applying a non-zero offset are dropped
+	CalibrationSigners []string `json:"calibration_signers"`
+	// CheckpointsFrom is the first block height minted with typed payloads,
+	// from which every 100th block carries a checkpoint. 0 for ledgers
+	// started on this version; older ledgers set the height they upgraded at
+	CheckpointsFrom int64 `json:"checkpoints_from"`
+	ReplicationTLS TLSClientConfig `json:"replication_tls"`
+	// Validators enables M-of-N block finalization: threshold signatures out of
+	// keys, collected from the other validators listed in peers. keys and
+	// threshold are only the bootstrap: the first block minted with them
+	// records them on the ledger, and the ledger's set applies from then on.
+	// A validator co-signs one header per height, remembered across
+	// restarts in storage_path + ".cosign"
+	Validators struct {
+		Keys      []string `json:"keys"`
+		Threshold int      `json:"threshold"`
+		Peers     []string `json:"peers"`
+	} `json:"validators"`
+	// Election replaces the static node_type: the lease holder mints, the rest follow
+	Election struct {
+		Enabled         bool   `json:"enabled"`
+		NodeID          string `json:"node_id"`
+		AdvertiseURL    string `json:"advertise_url"`
+		LeasePath       string `json:"lease_path"`
+		LeaseTTLSeconds int    `json:"lease_ttl_seconds"`
+	} `json:"election"`
+	// Signer selects where the node key lives: "local" (key_path, default)
+	// or "remote" (an aurum-signer daemon at address, unix:/path or https://)
+	Signer struct {
+		Mode    string          `json:"mode"`
+		Address string          `json:"address"`
+		TLS     TLSClientConfig `json:"tls"`
+	} `json:"signer"`
+	NodeHealth NodeHealthConfig `json:"node_health"`
+	// SpreadAlerts bounds the fiat-crypto basis (PAXG premium)
+	SpreadAlerts SpreadAlertConfig `json:"spread_alerts"`
 }
 
 var (
 	config Config
 	core   *AurumCore
 	anchor *CosmosAnchor
-	latestPrice float64
+	elector *Elector
+	nodeTracker *NodeTracker
+	latestPrice fixed.Price
 	latestCount int
 	priceMu     sync.RWMutex
+	// calibrationVersions refuses a node's calibration older than one it
+	// has already reported
+	calibrationVersions calibration.Versions
 )
 
 // --- Oracle Logic ---
 
-func fetchPrice(url string) (float64, error) {
+// seedCalibrationVersions primes the calibration replay guard with the
+// versions already recorded on the ledger for each node.
+func seedCalibrationVersions() {
+	for from := int64(0); ; {
+		batch := core.BlocksFrom(from, maxBlocksPerPage)
+		if len(batch) == 0 {
+			return
+		}
+		for _, b := range batch {
+			pu, ok := priceUpdateIn(b)
+			if !ok {
+				continue
+			}
+			for _, c := range pu.Calibrations {
+				calibrationVersions.Accept(c.Node, c.Version)
+			}
+		}
+		from += int64(len(batch))
+	}
+}
+
+func fetchPrice(url string) (NodeQuote, error) {
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Get(url + "/price")
 	if err != nil {
-		return 0, err
+		return NodeQuote{}, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, _ := io.ReadAll(resp.Body)
 
 	if resp.StatusCode != 200 {
-		return 0, fmt.Errorf("status %d", resp.StatusCode)
+		return NodeQuote{}, fmt.Errorf("status %d", resp.StatusCode)
 	}
 
 	var result map[string]interface{}
 	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		return 0, fmt.Errorf("bad json")
+		return NodeQuote{}, fmt.Errorf("bad json")
+	}
+	ts, _ := result["timestamp"].(float64)
+
+	// Offsets must be signed; a node reporting a calibration we cannot
+	// verify does not contribute
+	var cal struct {
+		Calibration *calibration.Signed `json:"calibration"`
+		SpreadBpsE8 *fixed.Price        `json:"spread_bps_e8"`
+		SpreadBps   *float64            `json:"spread_bps"` // nodes predating fixed point
+		QuoteAge    int64               `json:"oldest_quote_age_seconds"`
+		PriceE8     *fixed.Price        `json:"price_e8"`
+		Aggregate   struct {
+			PriceE8 *fixed.Price `json:"price_e8"`
+		} `json:"aggregate"`
+		FX struct {
+			Base    string                 `json:"base"`
+			RatesE8 map[string]fixed.Price `json:"rates_e8"`
+			Rates   map[string]float64     `json:"rates"` // nodes predating fixed point
+			Age     int64                  `json:"oldest_age_seconds"`
+		} `json:"fx"`
+	}
+	if err := json.Unmarshal(bodyBytes, &cal); err != nil {
+		return NodeQuote{}, fmt.Errorf("bad json: %w", err)
+	}
+	if cal.Calibration != nil {
+		if err := cal.Calibration.Verify(config.CalibrationSigners); err != nil {
+			return NodeQuote{}, fmt.Errorf("calibration rejected: %w", err)
+		}
+		if err := calibrationVersions.Accept(url, cal.Calibration.Calibration.Version); err != nil {
+			return NodeQuote{}, fmt.Errorf("calibration rejected: %w", err)
+		}
+	}
+
+	quote := NodeQuote{Timestamp: int64(ts), Calibration: cal.Calibration, QuoteAge: cal.QuoteAge}
+	if cal.SpreadBpsE8 != nil {
+		quote.SpreadBps = cal.SpreadBpsE8
+	} else if cal.SpreadBps != nil {
+		spread, err := fixed.FromFloat(*cal.SpreadBps)
+		if err != nil {
+			return NodeQuote{}, fmt.Errorf("bad spread_bps: %w", err)
+		}
+		quote.SpreadBps = &spread
+	}
+	if cal.Calibration != nil {
+		if quote.Offsets, err = calibrationOffsets(cal.Calibration); err != nil {
+			return NodeQuote{}, fmt.Errorf("calibration rejected: %w", err)
+		}
+	}
+	if cal.FX.Base == "USD" {
+		quote.FX, quote.FXAge = cal.FX.RatesE8, cal.FX.Age
+		if quote.Timestamp > 0 {
+			quote.FXAge += time.Now().Unix() - quote.Timestamp
+		}
+		if len(quote.FX) == 0 && len(cal.FX.Rates) > 0 {
+			quote.FX = map[string]fixed.Price{}
+			for c, r := range cal.FX.Rates {
+				if quote.FX[c], err = fixed.FromFloat(r); err != nil {
+					return NodeQuote{}, fmt.Errorf("bad fx rate %s: %w", c, err)
+				}
+			}
+		}
+	}
+	// Enforce freshness on the node's inputs, not just its response
+	if age, max := quote.DataAge(time.Now()), config.NodeHealth.withDefaults().MaxQuoteAgeSeconds; age > max {
+		return NodeQuote{}, fmt.Errorf("stale quotes: oldest input %ds old (max %ds)", age, max)
+	}
+
+	// Fixed point first; the float "price" fields are display only, read
+	// from nodes that predate price_e8
+	for _, p := range []*fixed.Price{cal.PriceE8, cal.Aggregate.PriceE8} {
+		if p != nil && *p > 0 {
+			quote.Price = *p
+			return quote, nil
+		}
 	}
 
 	// Strategy 1: Root "price"
 	if val, ok := result["price"].(float64); ok && val > 0 {
-		return val, nil
+		quote.Price, err = fixed.FromFloat(val)
+		return quote, err
 	}
 
 	// Strategy 2: "aggregate.price"
 	if agg, ok := result["aggregate"].(map[string]interface{}); ok {
 		if val, ok := agg["price"].(float64); ok && val > 0 {
-			return val, nil
+			quote.Price, err = fixed.FromFloat(val)
+			return quote, err
 		}
 	}
 
-	return 0, fmt.Errorf("price not found")
+	return NodeQuote{}, fmt.Errorf("price not found")
 }
 
-func aggregatePrices() (float64, int) {
-	type res struct {
-		url   string
-		price float64
-		err   error
-	}
-	ch := make(chan res, len(config.OracleSources))
+// Aggregate is the outcome of one polling round across the oracle nodes.
+type Aggregate struct {
+	Price   fixed.Price
+	Sources int
+	// Calibrations lists the signed calibration each contributing node applied
+	Calibrations []CalibrationRecord
+	// SpreadBps is the median PAXG premium across nodes; HasSpread is false
+	// when no node had both fiat and crypto quotes
+	SpreadBps fixed.Price
+	HasSpread bool
+	// FXInputs are the contributing nodes' FX rates
+	FXInputs []fxInput
+}
+
+func aggregatePrices() Aggregate {
+	ch := make(chan nodeResult, len(config.OracleSources))
 
 	for _, url := range config.OracleSources {
 		go func(u string) {
-			p, e := fetchPrice(u)
-			ch <- res{url: u, price: p, err: e}
+			start := time.Now()
+			if err := checkReady(u); err != nil {
+				ch <- nodeResult{url: u, latency: time.Since(start), err: err}
+				return
+			}
+			q, e := fetchPrice(u)
+			ch <- nodeResult{url: u, quote: q, latency: time.Since(start), err: e}
 		}(url)
 	}
 
-	var prices []float64
+	var results []nodeResult
+	var prices, all []fixed.Price
+	var calibrations []CalibrationRecord
+	var spreads []fixed.Price
+	var fxInputs []fxInput
 	for i := 0; i < len(config.OracleSources); i++ {
 		r := <-ch
-		if r.err == nil && r.price > 0 {
-			prices = append(prices, r.price)
-			log.Printf("  ✅ Source %s: $%.2f", r.url, r.price)
+		results = append(results, r)
+		if r.err == nil && r.quote.Price > 0 {
+			all = append(all, r.quote.Price)
+			if nodeTracker.IsQuarantined(r.url) {
+				log.Printf("  🚫 Source %s: $%s (quarantined, excluded)", r.url, r.quote.Price)
+				continue
+			}
+			prices = append(prices, r.quote.Price)
+			if max := config.NodeHealth.withDefaults().MaxFXAgeSeconds; r.quote.FX != nil && r.quote.FXAge > max {
+				log.Printf("  ⏳ Source %s: FX rates %ds old (max %ds), not used for FX", r.url, r.quote.FXAge, max)
+			} else if r.quote.FX != nil {
+				fxInputs = append(fxInputs, fxInput{Node: r.url, Rates: r.quote.FX, AgeSeconds: r.quote.FXAge})
+			}
+			if r.quote.SpreadBps != nil {
+				spreads = append(spreads, *r.quote.SpreadBps)
+			}
+			if cal := r.quote.Calibration; cal != nil {
+				calibrations = append(calibrations, CalibrationRecord{
+					Node:      r.url,
+					Version:   cal.Calibration.Version,
+					OffsetsE8: r.quote.Offsets,
+					SetBy:     cal.Calibration.SetBy,
+					SetAt:     cal.Calibration.SetAt,
+					Signer:    cal.Signer,
+					Signature: cal.Signature,
+					Digest:    cal.Calibration.Digest(),
+				})
+			}
+			log.Printf("  ✅ Source %s: $%s", r.url, r.quote.Price)
 		} else {
+			if r.err == nil {
+				r.err = fmt.Errorf("non-positive price")
+				results[len(results)-1] = r
+			}
 			log.Printf("  ❌ Source %s FAILED: %v", r.url, r.err)
 		}
 	}
 
-	if len(prices) == 0 {
-		return 0, 0
+	// Score against the healthy consensus; with none left, against everyone
+	// so quarantined nodes can still recover
+	consensus := all
+	if len(prices) > 0 {
+		consensus = prices
 	}
+	nodeTracker.RecordRound(results, fixed.Median(consensus))
 
-	sort.Float64s(prices)
-	median := prices[len(prices)/2]
-	return median, len(prices)
+	if len(prices) == 0 {
+		return Aggregate{}
+	}
+	agg := Aggregate{Price: fixed.Median(prices), Sources: len(prices), Calibrations: calibrations, FXInputs: fxInputs}
+	agg.SpreadBps, agg.HasSpread = medianSpread(spreads)
+	return agg
 }
 
 // --- The Ticker ---
 
-func startMiningTicker() {
-	ticker := time.NewTicker(60 * time.Second)
+// mintInterval is how often the leader mints a block.
+const mintInterval = 60 * time.Second
+
+func runMiningTicker(ctx context.Context) {
+	ticker := time.NewTicker(mintInterval)
+	defer ticker.Stop()
 	log.Println("⏳ Mining Ticker Started: Minting blocks every 60s...")
 	mintBlock()
-	for range ticker.C {
-		mintBlock()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("⏹️  Mining Ticker Stopped")
+			return
+		case <-ticker.C:
+			mintBlock()
+		}
 	}
 }
 
 func mintBlock() {
 	log.Println("🔨 MINTING: Aggregating prices...")
-	price, count := aggregatePrices()
+	agg := aggregatePrices()
+	price, count := agg.Price, agg.Sources
 	
 	if count == 0 {
 		log.Println("⚠️  Skipping block: No sources available")
 		return
 	}
 
-	// Update Live Cache (Critical for Real-Time API)
-	priceMu.Lock()
-	latestPrice = price
-	latestCount = count
-	priceMu.Unlock()
-
-	payload := map[string]interface{}{
-		"asset":     "XAU/USD",
-		"price":     price,
-		"sources":   count,
-		"timestamp": time.Now().Unix(),
+	update := &PriceUpdate{
+		Asset:        "XAU/USD",
+		Unit:         units.TroyOunce.Name,
+		PriceE8:      price,
+		Scale:        fixed.Scale,
+		Sources:      count,
+		Timestamp:    time.Now().Unix(),
+		Calibrations: agg.Calibrations,
+		FX:           deriveFX(price, agg.FXInputs),
+	}
+	if agg.HasSpread {
+		update.SpreadBps = &agg.SpreadBps
+	}
+	ours, err := newTransaction(update)
+	if err != nil {
+		log.Printf("❌ Ledger Error: %v", err)
+		return
 	}
 
-	block, err := core.AppendBlock(payload)
+	changePayloads, changes := pendingChangePayloads(core.Height())
+	receipts := takeAnchorReceipts()
+	payloads := append([]TxPayload{update}, changePayloads...)
+	for _, r := range receipts {
+		payloads = append(payloads, r)
+	}
+	block, err := core.AppendBlock(payloads...)
 	if err != nil {
+		requeueChanges(changes)
+		requeueAnchorReceipts(receipts)
 		log.Printf("❌ Ledger Error: %v", err)
 		return
 	}
+	if block.Transactions[0].TxHash != ours.TxHash {
+		// A previously signed block was re-proposed; this round's data waits
+		requeueChanges(changes)
+		requeueAnchorReceipts(receipts)
+		updateLiveCache(*block)
+		if pu, ok := priceUpdateIn(*block); ok {
+			log.Printf("📦 Block #%d MINTED (re-proposed from an earlier attempt). Price: $%s, %ds old", block.Index, pu.PriceE8, time.Now().Unix()-pu.Timestamp)
+		} else {
+			log.Printf("📦 Block #%d MINTED (re-proposed from an earlier attempt)", block.Index)
+		}
+	} else {
+		// Update Live Cache (Critical for Real-Time API)
+		priceMu.Lock()
+		latestPrice = price
+		latestCount = count
+		priceMu.Unlock()
+
+		log.Printf("📦 Block #%d MINTED. Price: $%s", block.Index, price)
+	}
 
-	log.Printf("📦 Block #%d MINTED. Price: $%.2f", block.Index, price)
+	if pu, ok := priceUpdateIn(*block); ok && pu.SpreadBps != nil {
+		checkSpread(block, pu.SpreadBps.Float())
+	}
 
 	if block.Index % 5 == 0 {
 		go anchor.Anchor(block.Index, block.MerkleRoot, block.Hash)
@@ -161,8 +418,27 @@ func mintBlock() {
 
 func handlePrice(w http.ResponseWriter, r *http.Request) {
 	delayed := r.URL.Query().Get("delayed") == "true"
+	// ?asset=XAU&quote=EUR: only gold is priced, in USD or any currency
+	// the block carries an FX derivation for
+	asset := strings.ToUpper(r.URL.Query().Get("asset"))
+	quote := strings.ToUpper(r.URL.Query().Get("quote"))
+	if asset == "" {
+		asset = "XAU"
+	}
+	if quote == "" {
+		quote = "USD"
+	}
+	if asset != "XAU" {
+		http.Error(w, "unsupported asset: "+asset, http.StatusBadRequest)
+		return
+	}
+	unit, err := units.Parse(r.URL.Query().Get("unit"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	var targetBlock Block
-	var price float64
+	var price fixed.Price
 	var sources int
 	
 	if delayed {
@@ -182,8 +458,9 @@ func handlePrice(w http.ResponseWriter, r *http.Request) {
 		core.mu.RUnlock()
 		
 		// Parse from Block Data (Historical)
-		if val, ok := targetBlock.Transactions[0].Data["price"].(float64); ok { price = val }
-		if val, ok := targetBlock.Transactions[0].Data["sources"].(float64); ok { sources = int(val) }
+		if pu, ok := priceUpdateIn(targetBlock); ok {
+			price, sources = pu.PriceE8, pu.Sources
+		}
 		
 	} else {
 		// REAL TIME LOGIC (Use Cache!)
@@ -202,9 +479,12 @@ func handlePrice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"price":        price,
+	resp := map[string]interface{}{
+		"asset":        asset,
+		"quote":        quote,
+		"unit":         unit.Name,
+		"unit_grams":   unit.Grams,
+		"scale":        fixed.Scale,
 		"sources":      sources,
 		"block_index":  targetBlock.Index,
 		"timestamp":    targetBlock.Timestamp,
@@ -212,12 +492,46 @@ func handlePrice(w http.ResponseWriter, r *http.Request) {
 		"merkle_root":  targetBlock.MerkleRoot,
 		"verification": "DUAL_CHAIN_SECURED",
 		"delayed_15m":  delayed,
-	})
+	}
+	// Every price is served as price_e8 (scale 1e8) with the exact decimal
+	// in "price" for display
+	if quote != "USD" {
+		converted, rate, err := priceInQuote(targetBlock, quote)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		// Served from the block so it matches the recorded derivation
+		var usd fixed.Price
+		if pu, ok := priceUpdateIn(targetBlock); ok {
+			usd = pu.PriceE8
+		}
+		resp["usd_price"], resp["usd_price_e8"] = json.Number(usd.String()), usd
+		resp["fx_rate"], resp["fx_rate_e8"] = json.Number(rate.String()), rate
+		price = converted
+	}
+	if unit != units.TroyOunce {
+		// The ledger prices per troy ounce
+		resp["price_per_troy_oz"], resp["price_per_troy_oz_e8"] = json.Number(price.String()), price
+		if price, err = units.FromTroyOunce(price, unit); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	resp["price"], resp["price_e8"] = json.Number(price.String()), price
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
 }
 
 func handleChain(w http.ResponseWriter, r *http.Request) {
+	status := core.GetChainStatus()
+	status["node_type"] = config.NodeType
+	if elector != nil {
+		status["election"] = elector.status()
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(core.GetChainStatus())
+	json.NewEncoder(w).Encode(status)
 }
 
 // --- Bootstrap ---
@@ -228,35 +542,88 @@ func loadConfig() {
 		log.Fatal("❌ Config not found: aurum_config.json")
 	}
 	json.Unmarshal(file, &config)
-}
-
-func loadKey() ed25519.PrivateKey {
-	data, err := os.ReadFile(config.KeyPath)
-	if err != nil {
-		pub, priv, _ := ed25519.GenerateKey(nil)
-		pkcs8, _ := x509.MarshalPKCS8PrivateKey(priv)
-		pemBlock := &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}
-		os.WriteFile(config.KeyPath, pem.EncodeToMemory(pemBlock), 0600)
-		os.WriteFile("key.hex", []byte(hex.EncodeToString(pub)), 0644)
-		return priv
-	}
-	block, _ := pem.Decode(data)
-	key, _ := x509.ParsePKCS8PrivateKey(block.Bytes)
-	return key.(ed25519.PrivateKey)
+	if config.Listener.Addr == "" {
+		config.Listener.Addr = ":" + config.ServerPort
+	}
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
 	log.Println(">>> I AM THE CACHED AGGREGATOR v7 (REAL-TIME FIX) <<<")
 	loadConfig()
-	privKey := loadKey()
-	core = NewAurumCore(config.StoragePath, privKey)
+	signer, err := loadSigner()
+	if err != nil {
+		log.Fatalf("❌ Signing key: %v", err)
+	}
+	core = NewAurumCore(config.StoragePath, signer)
+	core.SetCheckpointsFrom(config.CheckpointsFrom)
+	if last := core.lastLegacyHeight(); last >= config.CheckpointsFrom {
+		log.Fatalf("❌ Ledger has blocks minted before typed payloads up to height %d; set checkpoints_from to the height the ledger upgraded at (at least %d)", last, last+1)
+	}
+	log.Printf("🔑 Signing as %s", hex.EncodeToString(signer.PublicKey()))
+	if cosignGuard, err = NewCosignGuard(config.StoragePath + ".cosign"); err != nil {
+		log.Fatalf("❌ Cosign state: %v", err)
+	}
 	anchor = NewCosmosAnchor(config)
+	nodeTracker = NewNodeTracker(config.NodeHealth, config.OracleSources)
+	updateLiveCache(core.GetLatest())
+	seedCalibrationVersions()
+	go runCandleIndexer(context.Background())
+	go runSpreadIndexer(context.Background())
+
+	if config.Validators.Threshold > 0 {
+		vs, err := NewValidatorSet(config.Validators.Keys, config.Validators.Threshold)
+		if err != nil {
+			log.Fatalf("❌ Validator config: %v", err)
+		}
+		collector, err := NewSignatureCollector(config)
+		if err != nil {
+			log.Fatalf("❌ Validator config: %v", err)
+		}
+		core.SetBootstrapValidators(vs)
+		core.AddFinalizer(collector.Collect)
+		log.Printf("🔏 MULTI-SIGNER: blocks need %d of %d validator signatures", vs.Threshold, len(vs.Keys))
+	} else if core.multiSigner() && config.NodeType != "follower" {
+		log.Fatalf("❌ Validator config: the ledger records a validator set; configure validators to mint")
+	}
 
-	go startMiningTicker()
+	switch {
+	case config.Election.Enabled:
+		replicator, err := NewReplicator(config)
+		if err != nil {
+			log.Fatalf("❌ Replication config: %v", err)
+		}
+		elector, err = NewElector(config, replicator)
+		if err != nil {
+			log.Fatalf("❌ Election config: %v", err)
+		}
+		core.AddFinalizer(elector.Fence)
+		log.Printf("🗳️  ELECTION MODE: %s campaigning via %s", config.Election.NodeID, config.Election.LeasePath)
+		go elector.Run(context.Background())
+	case config.NodeType == "follower":
+		replicator, err := NewReplicator(config)
+		if err != nil {
+			log.Fatalf("❌ Replication config: %v", err)
+		}
+		log.Println("📡 FOLLOWER MODE: read-only, replicating from", config.ReplicationPeers)
+		go replicator.Run(context.Background())
+	default:
+		go runMiningTicker(context.Background())
+	}
 
 	http.HandleFunc("/price", handlePrice)
 	http.HandleFunc("/chain", handleChain)
+	http.HandleFunc("/blocks", handleBlocks)
+	http.HandleFunc("/blocks/stream", handleBlockStream)
+	http.HandleFunc("/cosign", handleCosign)
+	http.HandleFunc("/validators", handleValidators)
+	http.HandleFunc("/nodes", handleNodes)
+	http.HandleFunc("/spread", handleSpread)
+	http.HandleFunc("/candles", handleCandles)
 	
-	log.Printf("✅ Listening on :%s", config.ServerPort)
-	log.Fatal(http.ListenAndServe(":"+config.ServerPort, nil))
+	log.Printf("✅ Listening on %s (tls=%v)", config.Listener.Addr, config.Listener.TLSEnabled())
+	log.Fatal(listener.ListenAndServe(config.Listener, nil))
 }
\ No newline at end of file
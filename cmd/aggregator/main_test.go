@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+// nodeServer answers /price with body.
+func nodeServer(t *testing.T, body string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestFetchPrice(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantPrice  fixed.Price
+		wantSpread string // "" for none
+		wantErr    string
+	}{
+		{"fixed point", `{"aggregate":{"price_e8":265050000000},"spread_bps":10.01,"spread_bps_e8":1000812345}`,
+			265050000000, "10.00812345", ""},
+		{"legacy floats", `{"price":2650.5,"spread_bps":12.34}`, 265050000000, "12.34", ""},
+		{"no spread", `{"price_e8":265050000000}`, 265050000000, "", ""},
+		{"mistyped field", `{"price_e8":265050000000,"spread_bps_e8":"ten"}`, 0, "", "bad json"},
+		{"legacy fx", `{"price_e8":265050000000,"fx":{"base":"USD","rates":{"EUR":0.92}}}`, 265050000000, "", ""},
+		{"legacy fx out of range", `{"price_e8":265050000000,"fx":{"base":"USD","rates":{"EUR":1e300}}}`, 0, "", "bad fx rate EUR"},
+		{"no price", `{"spread_bps_e8":1000000000}`, 0, "", "price not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := fetchPrice(nodeServer(t, tt.body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || q.Price != tt.wantPrice {
+				t.Fatalf("got %s, %v; want %s", q.Price, err, tt.wantPrice)
+			}
+			switch {
+			case tt.wantSpread == "" && q.SpreadBps != nil:
+				t.Errorf("spread %s, want none", q.SpreadBps)
+			case tt.wantSpread != "" && (q.SpreadBps == nil || q.SpreadBps.String() != tt.wantSpread):
+				t.Errorf("spread %v, want %s", q.SpreadBps, tt.wantSpread)
+			}
+		})
+	}
+}
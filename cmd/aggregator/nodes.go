@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"aurum-oracle/pkg/calibration"
+	"aurum-oracle/pkg/fixed"
+)
+
+// NodeHealthConfig tunes oracle node scoring. Zero values take the defaults below.
+type NodeHealthConfig struct {
+	Window               int     `json:"window"`      // rounds kept per node
+	MinSamples           int     `json:"min_samples"` // rounds before a node can be quarantined
+	QuarantineScore      float64 `json:"quarantine_score"`
+	ReleaseScore         float64 `json:"release_score"`
+	MinQuarantineSeconds int     `json:"min_quarantine_seconds"`
+	MaxLatencyMs         int64   `json:"max_latency_ms"`
+	MaxStalenessSeconds  int64   `json:"max_staleness_seconds"`
+	MaxQuoteAgeSeconds   int64   `json:"max_quote_age_seconds"` // oldest source quote a node may use
+	MaxFXAgeSeconds      int64   `json:"max_fx_age_seconds"`    // oldest FX rate a node's fx may rest on
+	MaxDeviationBps      float64 `json:"max_deviation_bps"`
+}
+
+func (c NodeHealthConfig) withDefaults() NodeHealthConfig {
+	if c.Window <= 0 {
+		c.Window = 30
+	}
+	if c.MinSamples <= 0 {
+		c.MinSamples = 5
+	}
+	if c.QuarantineScore <= 0 {
+		c.QuarantineScore = 50
+	}
+	if c.ReleaseScore < c.QuarantineScore {
+		c.ReleaseScore = c.QuarantineScore + 20
+	}
+	if c.MinQuarantineSeconds <= 0 {
+		c.MinQuarantineSeconds = 300
+	}
+	if c.MaxLatencyMs <= 0 {
+		c.MaxLatencyMs = 5000
+	}
+	if c.MaxStalenessSeconds <= 0 {
+		c.MaxStalenessSeconds = 120
+	}
+	if c.MaxQuoteAgeSeconds <= 0 {
+		c.MaxQuoteAgeSeconds = 300
+	}
+	if c.MaxFXAgeSeconds <= 0 {
+		c.MaxFXAgeSeconds = 96 * 3600 // the ECB fixing has to last a long weekend
+	}
+	if c.MaxDeviationBps <= 0 {
+		c.MaxDeviationBps = 100
+	}
+	return c
+}
+
+// NodeQuote is what an oracle node answered for one round.
+type NodeQuote struct {
+	Price       fixed.Price
+	Timestamp   int64 // node clock, unix seconds; 0 if not reported
+	Calibration *calibration.Signed
+	Offsets     map[string]fixed.Price // Calibration's offsets in fixed point
+	SpreadBps   *fixed.Price           // PAXG premium over spot, when the node has both sides
+	QuoteAge    int64                  // oldest contributing source quote, seconds; 0 if not reported
+	FX          map[string]fixed.Price // CCY per USD, if the node reports FX
+	FXAge       int64                  // oldest FX rate behind FX, seconds, as of receipt
+}
+
+// DataAge is how old the node's oldest input was when we received it.
+func (q NodeQuote) DataAge(now time.Time) int64 {
+	age := q.QuoteAge
+	if q.Timestamp > 0 {
+		age += now.Unix() - q.Timestamp
+	}
+	return age
+}
+
+// calibrationOffsets converts a node's signed offsets to fixed point. They
+// are signed as decimals, so the conversion is exact.
+func calibrationOffsets(s *calibration.Signed) (map[string]fixed.Price, error) {
+	offsets := map[string]fixed.Price{}
+	for name, off := range s.Calibration.Offsets {
+		v, err := fixed.FromFloat(off)
+		if err != nil {
+			return nil, fmt.Errorf("offset for %s: %w", name, err)
+		}
+		offsets[name] = v
+	}
+	return offsets, nil
+}
+
+// observation is one round for one node. Deviation is only known for
+// rounds where the node answered and a consensus median existed.
+type observation struct {
+	latency      time.Duration
+	failed       bool
+	stale        bool
+	deviationBps float64
+	hasDeviation bool
+}
+
+type nodeHealth struct {
+	url          string
+	window       []observation
+	lastError    string
+	lastPrice    float64
+	lastSeen     time.Time
+	stalenessSec int64
+	quoteAge     int64
+	quarantined  bool
+	since        time.Time
+}
+
+// NodeTracker scores oracle nodes over a rolling window of rounds and
+// quarantines the ones that are persistently slow, failing, stale or off
+// consensus. Quarantined nodes are still polled so they can earn their way back.
+type NodeTracker struct {
+	cfg   NodeHealthConfig
+	mu    sync.RWMutex
+	nodes map[string]*nodeHealth
+}
+
+func NewNodeTracker(cfg NodeHealthConfig, urls []string) *NodeTracker {
+	t := &NodeTracker{cfg: cfg.withDefaults(), nodes: map[string]*nodeHealth{}}
+	for _, url := range urls {
+		t.node(url)
+	}
+	return t
+}
+
+func (t *NodeTracker) node(url string) *nodeHealth {
+	n, ok := t.nodes[url]
+	if !ok {
+		n = &nodeHealth{url: url}
+		t.nodes[url] = n
+	}
+	return n
+}
+
+func (t *NodeTracker) IsQuarantined(url string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	n, ok := t.nodes[url]
+	return ok && n.quarantined
+}
+
+// nodeResult is one node's answer (or failure) in an aggregation round.
+type nodeResult struct {
+	url     string
+	quote   NodeQuote
+	latency time.Duration
+	err     error
+}
+
+// RecordRound scores every node against the round's consensus median and
+// updates quarantine state.
+func (t *NodeTracker) RecordRound(results []nodeResult, median fixed.Price) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+
+	for _, r := range results {
+		n := t.node(r.url)
+		obs := observation{latency: r.latency, failed: r.err != nil}
+		if r.err != nil {
+			n.lastError = r.err.Error()
+		} else {
+			n.lastPrice = r.quote.Price.Float()
+			n.lastSeen = now
+			n.stalenessSec = 0
+			if r.quote.Timestamp > 0 {
+				n.stalenessSec = now.Unix() - r.quote.Timestamp
+			}
+			n.quoteAge = r.quote.QuoteAge
+			obs.stale = n.stalenessSec > t.cfg.MaxStalenessSeconds
+			if median > 0 {
+				obs.deviationBps = math.Abs((r.quote.Price - median).Float()) / median.Float() * 10000
+				obs.hasDeviation = true
+			}
+		}
+		n.window = append(n.window, obs)
+		if len(n.window) > t.cfg.Window {
+			n.window = n.window[len(n.window)-t.cfg.Window:]
+		}
+		t.updateQuarantine(n, now)
+	}
+}
+
+func (t *NodeTracker) updateQuarantine(n *nodeHealth, now time.Time) {
+	if len(n.window) < t.cfg.MinSamples {
+		return
+	}
+	score := t.score(n).Score
+	switch {
+	case !n.quarantined && score < t.cfg.QuarantineScore:
+		n.quarantined, n.since = true, now
+		log.Printf("🚫 Node %s quarantined (score %.0f)", n.url, score)
+	case n.quarantined && score >= t.cfg.ReleaseScore &&
+		now.Sub(n.since) >= time.Duration(t.cfg.MinQuarantineSeconds)*time.Second:
+		n.quarantined, n.since = false, now
+		log.Printf("✅ Node %s released from quarantine (score %.0f)", n.url, score)
+	}
+}
+
+// NodeStats is the /nodes view of one oracle node.
+type NodeStats struct {
+	URL              string  `json:"url"`
+	Score            float64 `json:"score"`
+	Samples          int     `json:"samples"`
+	ErrorRate        float64 `json:"error_rate"`
+	LatencyP50Ms     int64   `json:"latency_p50_ms"`
+	StaleRate        float64 `json:"stale_rate"`
+	StalenessSeconds int64   `json:"staleness_seconds"`
+	QuoteAgeSeconds  int64   `json:"quote_age_seconds"`
+	DeviationBps     float64 `json:"mean_deviation_bps"`
+	Quarantined      bool    `json:"quarantined"`
+	QuarantinedSince int64   `json:"quarantined_since,omitempty"`
+	LastPrice        float64 `json:"last_price,omitempty"`
+	LastSeen         int64   `json:"last_seen,omitempty"`
+	LastError        string  `json:"last_error,omitempty"`
+}
+
+// score rates the window out of 100 as a product of factors, so any one
+// problem can sink a node: errors and deviation (up to max_deviation_bps)
+// can take it to zero, staleness and latency can halve it each.
+func (t *NodeTracker) score(n *nodeHealth) NodeStats {
+	st := NodeStats{URL: n.url, Samples: len(n.window), Quarantined: n.quarantined,
+		StalenessSeconds: n.stalenessSec, QuoteAgeSeconds: n.quoteAge, LastPrice: n.lastPrice, LastError: n.lastError}
+	if n.quarantined {
+		st.QuarantinedSince = n.since.Unix()
+	}
+	if !n.lastSeen.IsZero() {
+		st.LastSeen = n.lastSeen.Unix()
+	}
+	if len(n.window) == 0 {
+		st.Score = 100
+		return st
+	}
+
+	var failures, stale, answered, deviations int
+	var devSum float64
+	var latencies []time.Duration
+	for _, o := range n.window {
+		latencies = append(latencies, o.latency)
+		if o.failed {
+			failures++
+			continue
+		}
+		answered++
+		if o.stale {
+			stale++
+		}
+		if o.hasDeviation {
+			deviations++
+			devSum += o.deviationBps
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	st.LatencyP50Ms = latencies[len(latencies)/2].Milliseconds()
+	st.ErrorRate = float64(failures) / float64(len(n.window))
+	if answered > 0 {
+		st.StaleRate = float64(stale) / float64(answered)
+	}
+	if deviations > 0 {
+		st.DeviationBps = devSum / float64(deviations)
+	}
+
+	score := 100.0
+	score *= 1 - st.ErrorRate
+	score *= 1 - math.Min(st.DeviationBps/t.cfg.MaxDeviationBps, 1)
+	score *= 1 - 0.5*st.StaleRate
+	score *= 1 - 0.5*math.Min(float64(st.LatencyP50Ms)/float64(t.cfg.MaxLatencyMs), 1)
+	st.Score = math.Round(score*10) / 10
+	return st
+}
+
+func (t *NodeTracker) Stats() []NodeStats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make([]NodeStats, 0, len(t.nodes))
+	for _, n := range t.nodes {
+		out = append(out, t.score(n))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].URL < out[j].URL })
+	return out
+}
+
+// checkReady asks a node's /ready whether it can currently meet its minimum
+// source count. Nodes predating /ready (404) are taken as ready.
+func checkReady(url string) error {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(url + "/ready")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNotFound:
+		return nil
+	case http.StatusServiceUnavailable:
+		var report struct {
+			Healthy int `json:"healthy_sources"`
+			Min     int `json:"min_sources"`
+		}
+		json.NewDecoder(resp.Body).Decode(&report)
+		return fmt.Errorf("not ready (%d of %d sources)", report.Healthy, report.Min)
+	}
+	return fmt.Errorf("ready check: status %d", resp.StatusCode)
+}
+
+func handleNodes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"nodes":  nodeTracker.Stats(),
+		"config": nodeTracker.cfg,
+	})
+}
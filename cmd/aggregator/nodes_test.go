@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+func TestRecordRoundDeviationBaseline(t *testing.T) {
+	low, high := fixed.Price(2000*fixed.One), fixed.Price(2010*fixed.One)
+	tracker := NewNodeTracker(NodeHealthConfig{}, []string{"a", "b"})
+	tracker.RecordRound([]nodeResult{
+		{url: "a", quote: NodeQuote{Price: low}},
+		{url: "b", quote: NodeQuote{Price: high}},
+	}, fixed.Median([]fixed.Price{low, high}))
+
+	// The median of two is their mean, so both sit 5/2005 off consensus
+	want := 5.0 / 2005 * 10000
+	for _, url := range []string{"a", "b"} {
+		obs := tracker.nodes[url].window[0]
+		if !obs.hasDeviation || math.Abs(obs.deviationBps-want) > 1e-9 {
+			t.Errorf("%s: deviation %v bps, want %v", url, obs.deviationBps, want)
+		}
+	}
+}
+
+func TestMedianSpread(t *testing.T) {
+	bps := func(v ...float64) []fixed.Price {
+		out := make([]fixed.Price, len(v))
+		for i, f := range v {
+			out[i], _ = fixed.FromFloat(f)
+		}
+		return out
+	}
+	tests := []struct {
+		values []fixed.Price
+		want   string
+		ok     bool
+	}{
+		{nil, "0", false},
+		{bps(12.5), "12.5", true},
+		{bps(30, 10, 20), "20", true},
+		{bps(10, 40, 20, 30), "25", true},
+		{bps(-3, 4), "0.5", true},
+		{bps(0.00000001, 0.00000002), "0.00000002", true}, // half rounds away from zero
+	}
+	for _, tt := range tests {
+		got, ok := medianSpread(tt.values)
+		if got.String() != tt.want || ok != tt.ok {
+			t.Errorf("medianSpread(%v) = %s, %v; want %s, %v", tt.values, got, ok, tt.want, tt.ok)
+		}
+	}
+}
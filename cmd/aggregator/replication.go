@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aurum-oracle/pkg/listener"
+)
+
+const (
+	maxBlocksPerPage    = 500
+	streamHeartbeat     = 15 * time.Second
+	streamIdleTimeout   = 45 * time.Second
+	replicationRetryMin = 1 * time.Second
+	replicationRetryMax = 30 * time.Second
+)
+
+// --- Leader Side: serving blocks ---
+
+func parseFrom(r *http.Request) (int64, error) {
+	raw := r.URL.Query().Get("from")
+	if raw == "" {
+		return 0, nil
+	}
+	from, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || from < 0 {
+		return 0, fmt.Errorf("invalid from")
+	}
+	return from, nil
+}
+
+// handleBlocks serves a page of blocks: /blocks?from=N&limit=M
+func handleBlocks(w http.ResponseWriter, r *http.Request) {
+	from, err := parseFrom(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	if limit > maxBlocksPerPage {
+		limit = maxBlocksPerPage
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"height": core.Height(),
+		"blocks": core.BlocksFrom(from, limit),
+	})
+}
+
+// handleBlockStream streams every block from height `from` onwards as
+// newline-delimited JSON, then keeps the connection open for new blocks.
+func handleBlockStream(w http.ResponseWriter, r *http.Request) {
+	next, err := parseFrom(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		// Grab the wait channel first so a block landing mid-send is not missed
+		wait := core.WaitForBlock()
+		for {
+			batch := core.BlocksFrom(next, maxBlocksPerPage)
+			if len(batch) == 0 {
+				break
+			}
+			for i := range batch {
+				if err := enc.Encode(&batch[i]); err != nil {
+					return
+				}
+			}
+			next += int64(len(batch))
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-wait:
+		case <-heartbeat.C:
+			// Whitespace between JSON values keeps idle proxies from cutting us off
+			if _, err := w.Write([]byte("\n")); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// --- Follower Side: replicating blocks ---
+
+// Replicator follows a leader's block stream and appends verified blocks
+// to the local ledger. It resumes from the local height after any disconnect.
+type Replicator struct {
+	peers  func() []string
+	client *http.Client
+
+	mu      sync.Mutex
+	trusted map[string]bool
+}
+
+func NewReplicator(cfg Config) (*Replicator, error) {
+	tlsConfig, err := listener.ClientTLS(cfg.ReplicationTLS.CAFile, cfg.ReplicationTLS.CertFile, cfg.ReplicationTLS.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	rep := &Replicator{
+		peers: func() []string { return cfg.ReplicationPeers },
+		// No overall timeout: the stream is long-lived, idleness is handled separately
+		client:  &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, ResponseHeaderTimeout: 10 * time.Second}},
+		trusted: make(map[string]bool),
+	}
+	if len(cfg.TrustedSigners) == 0 && len(cfg.Validators.Keys) == 0 {
+		// Without a configured key a follower would accept any forged chain
+		return nil, fmt.Errorf("replication requires trusted_signers or validators keys")
+	}
+	for _, key := range cfg.TrustedSigners {
+		rep.trusted[strings.ToLower(key)] = true
+	}
+	// Configured validators may sign the blocks before the validator genesis
+	for _, key := range cfg.Validators.Keys {
+		rep.trusted[strings.ToLower(key)] = true
+	}
+	return rep, nil
+}
+
+func (rep *Replicator) isTrusted(pubkey string) bool {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	return rep.trusted[strings.ToLower(pubkey)]
+}
+
+// Run replicates until ctx is cancelled, cycling through peers on failure.
+func (rep *Replicator) Run(ctx context.Context) {
+	backoff := replicationRetryMin
+	for i := 0; ctx.Err() == nil; i++ {
+		var err error
+		peer := "(none)"
+		startHeight := core.Height()
+		if peers := rep.peers(); len(peers) == 0 {
+			err = fmt.Errorf("no replication peers known")
+		} else {
+			peer = peers[i%len(peers)]
+			err = rep.stream(ctx, peer)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if core.Height() > startHeight {
+			backoff = replicationRetryMin
+		}
+		log.Printf("⚠️  Replication from %s interrupted at height %d: %v (retry in %s)", peer, core.Height(), err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > replicationRetryMax {
+			backoff = replicationRetryMax
+		}
+	}
+}
+
+func (rep *Replicator) stream(ctx context.Context, peer string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/blocks/stream?from=%d", strings.TrimSuffix(peer, "/"), core.Height())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := rep.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	log.Printf("🔁 Replicating from %s at height %d", peer, core.Height())
+
+	// The leader heartbeats well inside this window; silence means a dead link
+	idle := time.AfterFunc(streamIdleTimeout, cancel)
+	defer idle.Stop()
+
+	dec := json.NewDecoder(&idleReader{r: resp.Body, idle: idle})
+	for {
+		var b Block
+		if err := dec.Decode(&b); err != nil {
+			return err
+		}
+		if b.Index < core.Height() {
+			continue // already have it
+		}
+		if err := core.AppendVerified(b, rep.isTrusted); err != nil {
+			return fmt.Errorf("rejected block: %w", err)
+		}
+		updateLiveCache(b)
+		log.Printf("📥 Block #%d replicated from %s", b.Index, peer)
+	}
+}
+
+// idleReader pushes the idle deadline back on every read that returns data.
+type idleReader struct {
+	r    io.Reader
+	idle *time.Timer
+}
+
+func (ir *idleReader) Read(p []byte) (int, error) {
+	n, err := ir.r.Read(p)
+	if n > 0 {
+		ir.idle.Reset(streamIdleTimeout)
+	}
+	return n, err
+}
+
+// updateLiveCache refreshes the real-time /price cache from a ledger block.
+func updateLiveCache(b Block) {
+	pu, ok := priceUpdateIn(b)
+	if !ok || pu.PriceE8 <= 0 {
+		return
+	}
+	priceMu.Lock()
+	latestPrice = pu.PriceE8
+	latestCount = pu.Sources
+	priceMu.Unlock()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"log"
+	"os"
+
+	"aurum-oracle/pkg/keystore"
+	"aurum-oracle/pkg/listener"
+	"aurum-oracle/pkg/remotesigner"
+)
+
+// Signer produces ed25519 signatures for this node. The key itself may live
+// in process memory, a PKCS#11 token or a separate signer process; callers
+// only ever see the public half.
+type Signer interface {
+	PublicKey() ed25519.PublicKey
+	Sign(msg []byte) ([]byte, error)
+}
+
+// LocalSigner keeps the private key in memory (keystore-backed).
+type LocalSigner struct {
+	key ed25519.PrivateKey
+}
+
+func NewLocalSigner(key ed25519.PrivateKey) (*LocalSigner, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid ed25519 private key length %d", len(key))
+	}
+	return &LocalSigner{key: key}, nil
+}
+
+func (s *LocalSigner) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+func (s *LocalSigner) Sign(msg []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, msg), nil
+}
+
+// loadSigner opens the configured signer. It fails closed: a missing,
+// malformed or locked key is an error, never a reason to mint a fresh identity.
+func loadSigner() (Signer, error) {
+	switch config.Signer.Mode {
+	case "", "local":
+		return loadLocalSigner()
+	case "remote":
+		tlsConfig, err := listener.ClientTLS(config.Signer.TLS.CAFile, config.Signer.TLS.CertFile, config.Signer.TLS.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		// The client double-checks every signature it gets back
+		client, err := remotesigner.Dial(config.Signer.Address, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+	return nil, fmt.Errorf("unknown signer mode %q", config.Signer.Mode)
+}
+
+func loadLocalSigner() (Signer, error) {
+	if config.KeyPath == "" {
+		return nil, fmt.Errorf("key_path is not set")
+	}
+	key, encrypted, err := keystore.ReadFile(config.KeyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no key at %s (create one with `aurum-aggregator keygen`)", config.KeyPath)
+		}
+		return nil, fmt.Errorf("load %s: %w", config.KeyPath, err)
+	}
+	if !encrypted {
+		log.Printf("⚠️  %s is an unencrypted key; migrate it to a keystore with `aurum-aggregator keygen -import`", config.KeyPath)
+	}
+	return NewLocalSigner(key)
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+// SpreadAlertConfig sets the normal band for the PAXG premium. A block
+// whose spread is outside max_abs_bps, or more than max_zscore standard
+// deviations from the rolling mean, raises an alert.
+type SpreadAlertConfig struct {
+	Window     int     `json:"window"`      // blocks in the rolling window
+	MinSamples int     `json:"min_samples"` // before the z-score check applies
+	MaxAbsBps  float64 `json:"max_abs_bps"`
+	MaxZScore  float64 `json:"max_zscore"`
+	WebhookURL string  `json:"webhook_url"`
+}
+
+func (c SpreadAlertConfig) withDefaults() SpreadAlertConfig {
+	if c.Window <= 0 {
+		c.Window = 60
+	}
+	if c.MinSamples <= 0 {
+		c.MinSamples = 10
+	}
+	if c.MaxAbsBps <= 0 {
+		c.MaxAbsBps = 150
+	}
+	if c.MaxZScore <= 0 {
+		c.MaxZScore = 4
+	}
+	return c
+}
+
+// medianSpread combines the nodes' spreads for the block: the median, with
+// an even count taking the mean of the middle two.
+func medianSpread(values []fixed.Price) (fixed.Price, bool) {
+	if len(values) == 0 {
+		return 0, false
+	}
+	return fixed.Median(values), true
+}
+
+type spreadPoint struct {
+	Index     int64   `json:"index"`
+	Timestamp int64   `json:"timestamp"`
+	SpreadBps float64 `json:"spread_bps"`
+}
+
+// maxSpreadHistory bounds both the ring below and what /spread serves.
+const maxSpreadHistory = 10000
+
+// spreadIndex keeps the spreads of the last maxSpreadHistory blocks that
+// recorded one, in a ring, so neither minting nor /spread rescans the chain.
+var spreadIndex = struct {
+	sync.Mutex
+	next  int64 // first block not yet folded in
+	ring  []spreadPoint
+	start int // oldest point
+}{ring: make([]spreadPoint, 0, maxSpreadHistory)}
+
+// pushSpread adds p to the ring. Caller holds spreadIndex.
+func pushSpread(p spreadPoint) {
+	if len(spreadIndex.ring) < maxSpreadHistory {
+		spreadIndex.ring = append(spreadIndex.ring, p)
+		return
+	}
+	spreadIndex.ring[spreadIndex.start] = p
+	spreadIndex.start = (spreadIndex.start + 1) % maxSpreadHistory
+}
+
+// syncSpreads folds in every block appended since the last call.
+func syncSpreads() {
+	spreadIndex.Lock()
+	defer spreadIndex.Unlock()
+	for {
+		batch := core.BlocksFrom(spreadIndex.next, maxBlocksPerPage)
+		if len(batch) == 0 {
+			return
+		}
+		for _, b := range batch {
+			spreadIndex.next = b.Index + 1
+			if pu, ok := priceUpdateIn(b); ok && pu.SpreadBps != nil {
+				pushSpread(spreadPoint{Index: b.Index, Timestamp: b.Timestamp, SpreadBps: pu.SpreadBps.Float()})
+			}
+		}
+	}
+}
+
+// runSpreadIndexer keeps the ring current as blocks land, minted or
+// replicated, the way runCandleIndexer does for candles.
+func runSpreadIndexer(ctx context.Context) {
+	for {
+		wait := core.WaitForBlock()
+		syncSpreads()
+		select {
+		case <-ctx.Done():
+			return
+		case <-wait:
+		}
+	}
+}
+
+// spreadHistory returns the last n blocks that recorded a spread, oldest first.
+func spreadHistory(n int) []spreadPoint {
+	// Catches up on a block that landed since the indexer last ran
+	syncSpreads()
+	spreadIndex.Lock()
+	defer spreadIndex.Unlock()
+	size := len(spreadIndex.ring)
+	if n > size {
+		n = size
+	}
+	out := make([]spreadPoint, n)
+	for i := range out {
+		out[i] = spreadIndex.ring[(spreadIndex.start+size-n+i)%size]
+	}
+	return out
+}
+
+type spreadStats struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+func computeSpreadStats(points []spreadPoint) spreadStats {
+	st := spreadStats{Count: len(points)}
+	if len(points) == 0 {
+		return st
+	}
+	st.Min, st.Max = points[0].SpreadBps, points[0].SpreadBps
+	var sum float64
+	for _, p := range points {
+		sum += p.SpreadBps
+		st.Min = math.Min(st.Min, p.SpreadBps)
+		st.Max = math.Max(st.Max, p.SpreadBps)
+	}
+	st.Mean = sum / float64(len(points))
+	var sq float64
+	for _, p := range points {
+		sq += (p.SpreadBps - st.Mean) * (p.SpreadBps - st.Mean)
+	}
+	st.StdDev = math.Sqrt(sq / float64(len(points)))
+	return st
+}
+
+// --- Alerts ---
+
+var spreadAlert struct {
+	sync.Mutex
+	active bool
+	reason string
+	since  int64
+}
+
+// checkSpread compares a freshly minted block's spread against the band
+// formed by the blocks before it. It alerts on entering the abnormal state
+// and logs the recovery, rather than repeating every block.
+func checkSpread(b *Block, bps float64) {
+	cfg := config.SpreadAlerts.withDefaults()
+	history := spreadHistory(cfg.Window + 1)
+	if n := len(history); n > 0 && history[n-1].Index == b.Index {
+		history = history[:n-1]
+	}
+	st := computeSpreadStats(history)
+
+	reason := ""
+	switch {
+	case math.Abs(bps) > cfg.MaxAbsBps:
+		reason = fmt.Sprintf("spread %.2f bps exceeds %.0f bps", bps, cfg.MaxAbsBps)
+	case st.Count >= cfg.MinSamples && st.StdDev > 0 && math.Abs(bps-st.Mean)/st.StdDev > cfg.MaxZScore:
+		reason = fmt.Sprintf("spread %.2f bps is %.1f sigma from its rolling mean %.2f bps", bps, math.Abs(bps-st.Mean)/st.StdDev, st.Mean)
+	}
+
+	spreadAlert.Lock()
+	defer spreadAlert.Unlock()
+	switch {
+	case reason != "" && !spreadAlert.active:
+		spreadAlert.active, spreadAlert.reason, spreadAlert.since = true, reason, b.Timestamp
+		log.Printf("🚨 SPREAD ALERT at block #%d: %s (broken source or PAXG depeg?)", b.Index, reason)
+		go sendSpreadAlert(cfg.WebhookURL, b, bps, reason, st)
+	case reason == "" && spreadAlert.active:
+		log.Printf("✅ Spread back in band at block #%d: %.2f bps", b.Index, bps)
+		spreadAlert.active, spreadAlert.reason, spreadAlert.since = false, "", 0
+		go sendSpreadAlert(cfg.WebhookURL, b, bps, "", st)
+	}
+}
+
+func sendSpreadAlert(url string, b *Block, bps float64, reason string, st spreadStats) {
+	if url == "" {
+		return
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"alert":       "spread",
+		"firing":      reason != "",
+		"reason":      reason,
+		"block_index": b.Index,
+		"spread_bps":  bps,
+		"window":      st,
+	})
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("⚠️  Spread alert webhook: %v", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// handleSpread serves spread history with rolling statistics.
+// ?limit=N (default 100) points; ?window=N blocks for the statistics.
+func handleSpread(w http.ResponseWriter, r *http.Request) {
+	cfg := config.SpreadAlerts.withDefaults()
+	limit, window := 100, cfg.Window
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= maxSpreadHistory {
+		limit = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("window")); err == nil && v > 0 && v <= maxSpreadHistory {
+		window = v
+	}
+
+	history := spreadHistory(limit)
+	windowPoints := history
+	if len(windowPoints) > window {
+		windowPoints = windowPoints[len(windowPoints)-window:]
+	} else if window > limit {
+		windowPoints = spreadHistory(window)
+	}
+	st := computeSpreadStats(windowPoints)
+
+	resp := map[string]interface{}{
+		"history": history,
+		"window":  st,
+		"band": map[string]interface{}{
+			"max_abs_bps": cfg.MaxAbsBps,
+			"max_zscore":  cfg.MaxZScore,
+		},
+	}
+	if n := len(history); n > 0 {
+		latest := history[n-1].SpreadBps
+		resp["latest_bps"] = latest
+		if st.StdDev > 0 {
+			resp["zscore"] = (latest - st.Mean) / st.StdDev
+		}
+	}
+	spreadAlert.Lock()
+	resp["alert"] = map[string]interface{}{
+		"active": spreadAlert.active,
+		"reason": spreadAlert.reason,
+		"since":  spreadAlert.since,
+	}
+	spreadAlert.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
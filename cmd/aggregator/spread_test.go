@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+// withCore points the package-level ledger, and the spread index built
+// from it, at c for the duration of a test.
+func withCore(t *testing.T, c *AurumCore) {
+	t.Helper()
+	saved := core
+	core = c
+	resetSpreads := func() {
+		spreadIndex.Lock()
+		spreadIndex.next, spreadIndex.ring, spreadIndex.start = 0, make([]spreadPoint, 0, maxSpreadHistory), 0
+		spreadIndex.Unlock()
+	}
+	resetSpreads()
+	t.Cleanup(func() {
+		core = saved
+		resetSpreads()
+	})
+}
+
+func TestSpreadHistory(t *testing.T) {
+	c := newTestCore(t, newTestSigner(t, 1))
+	withCore(t, c)
+	for i := int64(1); i <= 5; i++ {
+		pu := testUpdate(i)
+		if i != 3 {
+			bps := fixed.Price(i) * fixed.One
+			pu.SpreadBps = &bps
+		}
+		mint(t, c, pu)
+	}
+
+	got := spreadHistory(3)
+	if len(got) != 3 || got[0].Index != 1 || got[1].Index != 3 || got[2].Index != 4 {
+		t.Fatalf("last 3 spreads: %+v", got)
+	}
+	if got[2].SpreadBps != 5 {
+		t.Errorf("latest spread %v, want 5", got[2].SpreadBps)
+	}
+	if got := spreadHistory(100); len(got) != 4 {
+		t.Errorf("history longer than the chain: %d points", len(got))
+	}
+}
+
+func TestSpreadRingWraps(t *testing.T) {
+	withCore(t, newTestCore(t, newTestSigner(t, 1)))
+	spreadIndex.Lock()
+	for i := int64(0); i < maxSpreadHistory+5; i++ {
+		pushSpread(spreadPoint{Index: i, SpreadBps: float64(i)})
+	}
+	spreadIndex.Unlock()
+
+	got := spreadHistory(maxSpreadHistory + 10)
+	if len(got) != maxSpreadHistory || got[0].Index != 5 || got[len(got)-1].Index != maxSpreadHistory+4 {
+		t.Fatalf("wrapped ring: %d points from %d to %d", len(got), got[0].Index, got[len(got)-1].Index)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Index != got[i-1].Index+1 {
+			t.Fatalf("out of order at %d: %d after %d", i, got[i].Index, got[i-1].Index)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"aurum-oracle/pkg/canonjson"
+	"aurum-oracle/pkg/fixed"
+	"aurum-oracle/pkg/units"
+)
+
+// --- Transaction Payloads ---
+//
+// Every transaction holds one typed payload: a JSON object carrying "type"
+// and "version". TxHash is the SHA-256 of the payload in the encoding the
+// transaction names (see hashTransactionData). Transactions written before
+// payloads were typed are price updates with no type or version: they
+// decode as price_update version 0.
+
+const (
+	priceUpdateType   = "price_update"
+	anchorReceiptType = "anchor_receipt"
+	checkpointType    = "checkpoint"
+	// validatorGenesisType and validatorChangeType are defined with ValidatorChange
+)
+
+// checkpointInterval is how often a block commits to its predecessor with a
+// Checkpoint transaction.
+const checkpointInterval = 100
+
+// TxPayload is the typed content of a transaction.
+type TxPayload interface {
+	TxType() string
+	TxVersion() int
+}
+
+// PriceUpdate is the XAU/USD price of one round (type "price_update").
+// Version 2 holds no floats: the spread and calibration offsets are fixed
+// point like the price.
+type PriceUpdate struct {
+	Asset        string              `json:"asset"`
+	Unit         string              `json:"unit"`
+	PriceE8      fixed.Price         `json:"price_e8"`
+	Scale        int                 `json:"scale"`
+	Sources      int                 `json:"sources"`
+	Timestamp    int64               `json:"timestamp"`
+	Calibrations []CalibrationRecord `json:"calibrations,omitempty"`
+	SpreadBps    *fixed.Price        `json:"spread_bps_e8,omitempty"`
+	FX           *FXDerivation       `json:"fx,omitempty"`
+}
+
+func (p *PriceUpdate) TxType() string { return priceUpdateType }
+func (p *PriceUpdate) TxVersion() int { return 2 }
+
+// CalibrationRecord is the signed calibration a contributing node applied.
+type CalibrationRecord struct {
+	Node      string                 `json:"node"`
+	Version   int                    `json:"version"`
+	OffsetsE8 map[string]fixed.Price `json:"offsets_e8"`
+	SetBy     string                 `json:"set_by"`
+	SetAt     int64                  `json:"set_at"`
+	Signer    string                 `json:"signer"`
+	Signature string                 `json:"signature"`
+	Digest    string                 `json:"digest"`
+}
+
+// AnchorReceipt records that a block's Merkle root was anchored on Cosmos.
+type AnchorReceipt struct {
+	ChainID     string `json:"chain_id"`
+	Height      int64  `json:"height"` // the anchored block
+	BlockHash   string `json:"block_hash"`
+	MerkleRoot  string `json:"merkle_root"`
+	PayloadHash string `json:"payload_hash"` // SHA-256 of the anchor message sent
+	TxHash      string `json:"tx_hash"`      // the Cosmos transaction that carried it
+	AnchoredAt  int64  `json:"anchored_at"`
+}
+
+func (a *AnchorReceipt) TxType() string { return anchorReceiptType }
+func (a *AnchorReceipt) TxVersion() int { return 1 }
+
+// ValidatorGenesisTx records the first validator set. The block holding it
+// is the first one that set must sign.
+type ValidatorGenesisTx struct {
+	Keys      []string `json:"keys"`
+	Threshold int      `json:"threshold"`
+}
+
+func (v *ValidatorGenesisTx) TxType() string { return validatorGenesisType }
+func (v *ValidatorGenesisTx) TxVersion() int { return 1 }
+
+// ValidatorChangeTx carries an approved validator set change.
+type ValidatorChangeTx struct {
+	Change ValidatorChange `json:"change"`
+}
+
+func (v *ValidatorChangeTx) TxType() string { return validatorChangeType }
+func (v *ValidatorChangeTx) TxVersion() int { return 1 }
+
+// Checkpoint commits to the block before the one holding it, and to the
+// validator set that signs it (multi-signer mode).
+type Checkpoint struct {
+	Height       int64  `json:"height"`
+	BlockHash    string `json:"block_hash"`
+	ValidatorSet string `json:"validator_set,omitempty"`
+}
+
+func (c *Checkpoint) TxType() string { return checkpointType }
+func (c *Checkpoint) TxVersion() int { return 1 }
+
+// --- Decoder Registry ---
+
+type txKind struct {
+	Type    string
+	Version int
+}
+
+var txDecoders = map[txKind]func(data []byte) (TxPayload, error){}
+
+// registerTxDecoder adds the decoder for one version of a transaction type.
+func registerTxDecoder(typ string, version int, decode func(data []byte) (TxPayload, error)) {
+	k := txKind{typ, version}
+	if _, dup := txDecoders[k]; dup {
+		panic(fmt.Sprintf("duplicate decoder for %s v%d", typ, version))
+	}
+	txDecoders[k] = decode
+}
+
+func init() {
+	registerTxDecoder(priceUpdateType, 0, decodeLegacyPriceUpdate)
+	registerTxDecoder(priceUpdateType, 2, func(data []byte) (TxPayload, error) {
+		var p PriceUpdate
+		return &p, json.Unmarshal(data, &p)
+	})
+	registerTxDecoder(anchorReceiptType, 1, func(data []byte) (TxPayload, error) {
+		var a AnchorReceipt
+		return &a, json.Unmarshal(data, &a)
+	})
+	registerTxDecoder(validatorGenesisType, 1, func(data []byte) (TxPayload, error) {
+		var v ValidatorGenesisTx
+		return &v, json.Unmarshal(data, &v)
+	})
+	registerTxDecoder(validatorChangeType, 1, func(data []byte) (TxPayload, error) {
+		var v ValidatorChangeTx
+		return &v, json.Unmarshal(data, &v)
+	})
+	registerTxDecoder(checkpointType, 1, func(data []byte) (TxPayload, error) {
+		var c Checkpoint
+		return &c, json.Unmarshal(data, &c)
+	})
+}
+
+// storedKind reads the type and version a payload was written with.
+func storedKind(data []byte) (txKind, error) {
+	var head struct {
+		Type    string `json:"type"`
+		Version int    `json:"version"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return txKind{}, err
+	}
+	if head.Type == "" {
+		head.Type = priceUpdateType
+	}
+	return txKind{head.Type, head.Version}, nil
+}
+
+// decodeTx reads a stored payload with the decoder for its type and version.
+func decodeTx(data []byte) (TxPayload, error) {
+	k, err := storedKind(data)
+	if err != nil {
+		return nil, err
+	}
+	decode, ok := txDecoders[k]
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction type %q version %d", k.Type, k.Version)
+	}
+	return decode(data)
+}
+
+// decodeLegacyPriceUpdate reads price transactions minted before typed
+// payloads: the float "price" predates price_e8, and fx may carry floats.
+func decodeLegacyPriceUpdate(data []byte) (TxPayload, error) {
+	var v0 struct {
+		PriceUpdate
+		Price *float64        `json:"price"`
+		FX    json.RawMessage `json:"fx"`
+	}
+	if err := json.Unmarshal(data, &v0); err != nil {
+		return nil, err
+	}
+	p := v0.PriceUpdate
+	if p.PriceE8 == 0 && v0.Price != nil {
+		price, err := fixed.FromFloat(*v0.Price)
+		if err != nil {
+			return nil, err
+		}
+		p.PriceE8 = price
+	}
+	if p.Unit == "" {
+		p.Unit = units.TroyOunce.Name
+	}
+	if p.Scale == 0 {
+		p.Scale = fixed.Scale
+	}
+	if len(v0.FX) > 0 {
+		p.FX = decodeLegacyFX(v0.FX)
+	}
+	return &p, decodeFloatPriceFields(data, &p)
+}
+
+// decodeFloatPriceFields reads the spread and calibration offsets of
+// untyped price updates, which carried them as floats.
+func decodeFloatPriceFields(data []byte, p *PriceUpdate) error {
+	var v1 struct {
+		SpreadBps    *float64 `json:"spread_bps"`
+		Calibrations []struct {
+			Offsets map[string]float64 `json:"offsets"`
+		} `json:"calibrations"`
+	}
+	if err := json.Unmarshal(data, &v1); err != nil {
+		return err
+	}
+	if v1.SpreadBps != nil {
+		spread, err := fixed.FromFloat(*v1.SpreadBps)
+		if err != nil {
+			return err
+		}
+		p.SpreadBps = &spread
+	}
+	for i, c := range v1.Calibrations {
+		if i >= len(p.Calibrations) || len(c.Offsets) == 0 {
+			continue
+		}
+		p.Calibrations[i].OffsetsE8 = map[string]fixed.Price{}
+		for name, off := range c.Offsets {
+			v, err := fixed.FromFloat(off)
+			if err != nil {
+				return err
+			}
+			p.Calibrations[i].OffsetsE8[name] = v
+		}
+	}
+	return nil
+}
+
+// --- Canonical Encoding ---
+
+// encodeTx serializes p in canonical (RFC 8785) form, with its type and version.
+func encodeTx(p TxPayload) (json.RawMessage, error) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	fields["type"], fields["version"] = p.TxType(), p.TxVersion()
+	return canonjson.Marshal(fields)
+}
+
+// legacyCanonicalTx is the form transactions without an encoding were
+// hashed over: encoding/json output of the decoded payload (sorted keys,
+// numbers as written, HTML characters escaped).
+func legacyCanonicalTx(data []byte) ([]byte, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// --- Block Accessors ---
+
+// Payload decodes the transaction's typed content.
+func (tx Transaction) Payload() (TxPayload, error) {
+	return decodeTx(tx.Data)
+}
+
+// priceUpdateIn returns the block's price transaction.
+func priceUpdateIn(b Block) (*PriceUpdate, bool) {
+	for _, tx := range b.Transactions {
+		if p, err := tx.Payload(); err == nil {
+			if pu, ok := p.(*PriceUpdate); ok {
+				return pu, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// checkpointFor is the checkpoint a block at index must carry, or nil.
+func (core *AurumCore) checkpointFor(index int64, prevHash string) *Checkpoint {
+	if index == 0 || index%checkpointInterval != 0 || index < core.checkpointsFrom {
+		return nil
+	}
+	c := &Checkpoint{Height: index - 1, BlockHash: prevHash}
+	if vs := core.ValidatorSetAt(index); vs != nil {
+		c.ValidatorSet = vs.Hash()
+	}
+	return c
+}
+
+// SetCheckpointsFrom sets the first height that carries checkpoints, the
+// first block a ledger minted with typed payloads. Blocks below it are
+// verified without one. Call it before blocks are minted or verified.
+func (core *AurumCore) SetCheckpointsFrom(height int64) {
+	core.checkpointsFrom = height
+}
+
+// lastLegacyHeight is the highest block holding a version 0 transaction,
+// minted before typed payloads, or -1.
+func (core *AurumCore) lastLegacyHeight() int64 {
+	core.mu.RLock()
+	defer core.mu.RUnlock()
+	for i := len(core.blocks) - 1; i >= 0; i-- {
+		for _, tx := range core.blocks[i].Transactions {
+			if k, err := storedKind(tx.Data); err == nil && k.Version == 0 {
+				return core.blocks[i].Index
+			}
+		}
+	}
+	return -1
+}
+
+// verifyCheckpoint checks b carries exactly the checkpoint its height calls
+// for.
+func (core *AurumCore) verifyCheckpoint(b *Block) error {
+	want := core.checkpointFor(b.Index, b.PreviousHash)
+	var found []*Checkpoint
+	for _, tx := range b.Transactions {
+		if p, err := tx.Payload(); err == nil {
+			if c, ok := p.(*Checkpoint); ok {
+				found = append(found, c)
+			}
+		}
+	}
+	switch {
+	case len(found) == 0 && want == nil:
+		return nil
+	case want == nil:
+		return fmt.Errorf("block %d: unexpected checkpoint", b.Index)
+	case len(found) != 1:
+		return fmt.Errorf("block %d: expected one checkpoint, found %d", b.Index, len(found))
+	case *found[0] != *want:
+		return fmt.Errorf("block %d: checkpoint does not match the chain", b.Index)
+	}
+	return nil
+}
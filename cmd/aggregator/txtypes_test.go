@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+func TestDecodePriceUpdateVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"version 0", `{"asset":"XAU/USD","price":2650.5,"sources":3,"spread_bps":12.34,"calibrations":[{"node":"n1","version":4,"offsets":{"Swissquote":-0.35}}]}`},
+		{"version 2", `{"type":"price_update","version":2,"asset":"XAU/USD","unit":"troy_oz","price_e8":265050000000,"scale":8,"sources":3,"spread_bps_e8":1234000000,"calibrations":[{"node":"n1","version":4,"offsets_e8":{"Swissquote":-35000000}}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := decodeTx([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pu, ok := p.(*PriceUpdate)
+			if !ok {
+				t.Fatalf("decoded %T", p)
+			}
+			if pu.PriceE8 != 265050000000 || pu.Unit != "troy_oz" || pu.Scale != fixed.Scale {
+				t.Errorf("price %s %s scale %d", pu.PriceE8, pu.Unit, pu.Scale)
+			}
+			if pu.SpreadBps == nil || *pu.SpreadBps != 1234000000 {
+				t.Errorf("spread = %v, want 12.34 bps", pu.SpreadBps)
+			}
+			if len(pu.Calibrations) != 1 || pu.Calibrations[0].Version != 4 || pu.Calibrations[0].OffsetsE8["Swissquote"] != -35000000 {
+				t.Errorf("calibrations = %+v", pu.Calibrations)
+			}
+		})
+	}
+}
+
+func TestEncodePriceUpdateHasNoFloats(t *testing.T) {
+	spread := fixed.Price(1234000000)
+	data, err := encodeTx(&PriceUpdate{
+		Asset: "XAU/USD", Unit: "troy_oz", PriceE8: 265050000000, Scale: fixed.Scale, Sources: 3,
+		SpreadBps:    &spread,
+		Calibrations: []CalibrationRecord{{Node: "n1", Version: 4, OffsetsE8: map[string]fixed.Price{"Swissquote": -35000000}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(data)
+	for _, want := range []string{`"spread_bps_e8":1234000000`, `"offsets_e8":{"Swissquote":-35000000}`, `"version":2`} {
+		if !strings.Contains(s, want) {
+			t.Errorf("encoding lacks %s: %s", want, s)
+		}
+	}
+}
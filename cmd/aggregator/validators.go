@@ -0,0 +1,654 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aurum-oracle/pkg/listener"
+)
+
+const cosignTimeout = 5 * time.Second
+
+// ValidatorSet is the group of aggregator keys that finalize blocks.
+// A block is final once Threshold distinct members have signed its header.
+type ValidatorSet struct {
+	Keys      []string `json:"keys"`
+	Threshold int      `json:"threshold"`
+}
+
+func NewValidatorSet(keys []string, threshold int) (*ValidatorSet, error) {
+	vs := &ValidatorSet{Threshold: threshold}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		if raw, err := hex.DecodeString(key); err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("validator key %q is not a hex ed25519 public key", key)
+		}
+		if !seen[key] {
+			seen[key] = true
+			vs.Keys = append(vs.Keys, key)
+		}
+	}
+	if threshold < 1 || threshold > len(vs.Keys) {
+		return nil, fmt.Errorf("threshold %d must be between 1 and %d", threshold, len(vs.Keys))
+	}
+	return vs, nil
+}
+
+func (vs *ValidatorSet) Contains(pubkey string) bool {
+	pubkey = strings.ToLower(pubkey)
+	for _, key := range vs.Keys {
+		if key == pubkey {
+			return true
+		}
+	}
+	return false
+}
+
+// countValid returns how many distinct set members produced a valid signature.
+func (vs *ValidatorSet) countValid(b *Block) int {
+	msg := signingMessage(b)
+	signed := map[string]bool{}
+	for _, s := range b.Signatures {
+		key := strings.ToLower(s.Pubkey)
+		if signed[key] || !vs.Contains(key) {
+			continue
+		}
+		if verifySignature(key, s.Signature, msg) {
+			signed[key] = true
+		}
+	}
+	return len(signed)
+}
+
+// VerifyQuorum checks that at least Threshold validators signed b.
+func (vs *ValidatorSet) VerifyQuorum(b *Block) error {
+	if n := vs.countValid(b); n < vs.Threshold {
+		return fmt.Errorf("block %d: %d of %d required validator signatures", b.Index, n, vs.Threshold)
+	}
+	return nil
+}
+
+// Hash identifies a set exactly; change approvals commit to it so they
+// cannot be replayed against a later set.
+func (vs *ValidatorSet) Hash() string {
+	keys := append([]string(nil), vs.Keys...)
+	sort.Strings(keys)
+	h := sha256.Sum256([]byte(fmt.Sprintf("AURUM|validators|v1|%d|%s", vs.Threshold, strings.Join(keys, ","))))
+	return hex.EncodeToString(h[:])
+}
+
+// --- Validator Set History ---
+
+// validatorEpoch is a set and the first height it governs.
+type validatorEpoch struct {
+	From int64         `json:"from_height"`
+	Set  *ValidatorSet `json:"set"`
+}
+
+// SetBootstrapValidators configures the set this node proposes as the
+// validator genesis. It only takes effect through the ledger: the next block
+// minted without a recorded set carries it as a validator_genesis transaction.
+func (core *AurumCore) SetBootstrapValidators(vs *ValidatorSet) {
+	core.vmu.Lock()
+	core.bootstrap = vs
+	core.vmu.Unlock()
+	if current := core.ValidatorSetAt(core.Height()); current != nil && current.Hash() != vs.Hash() {
+		log.Printf("⚠️  Configured validators differ from the set on the ledger (%s); the ledger's set applies", current.Hash())
+	}
+}
+
+// isBootstrap reports whether vs is the configured bootstrap set.
+func (core *AurumCore) isBootstrap(vs *ValidatorSet) bool {
+	core.vmu.RLock()
+	defer core.vmu.RUnlock()
+	return core.bootstrap != nil && core.bootstrap.Hash() == vs.Hash()
+}
+
+func (core *AurumCore) multiSigner() bool {
+	core.vmu.RLock()
+	defer core.vmu.RUnlock()
+	return len(core.validatorEpochs) > 0 || core.bootstrap != nil
+}
+
+// ValidatorSetAt returns the set that must sign the block at height, or nil
+// in single-signer mode. It is derived from the ledger alone: nil before the
+// block recording the validator genesis.
+func (core *AurumCore) ValidatorSetAt(height int64) *ValidatorSet {
+	core.vmu.RLock()
+	defer core.vmu.RUnlock()
+	for i := len(core.validatorEpochs) - 1; i >= 0; i-- {
+		if core.validatorEpochs[i].From <= height {
+			return core.validatorEpochs[i].Set
+		}
+	}
+	return nil
+}
+
+// validatorSetFor returns the set that must sign b: the one in force at its
+// height or, for the block that records the genesis, the set it records.
+func (core *AurumCore) validatorSetFor(b *Block) *ValidatorSet {
+	if vs := core.ValidatorSetAt(b.Index); vs != nil {
+		return vs
+	}
+	return validatorGenesisIn(b)
+}
+
+func (core *AurumCore) validatorHistory() []validatorEpoch {
+	core.vmu.RLock()
+	defer core.vmu.RUnlock()
+	return append([]validatorEpoch(nil), core.validatorEpochs...)
+}
+
+// pendingGenesis is the validator genesis the block at index must carry:
+// the configured bootstrap set, until the ledger records one.
+func (core *AurumCore) pendingGenesis(index int64) *ValidatorGenesisTx {
+	core.vmu.RLock()
+	vs := core.bootstrap
+	core.vmu.RUnlock()
+	if vs == nil || core.ValidatorSetAt(index) != nil {
+		return nil
+	}
+	return &ValidatorGenesisTx{Keys: vs.Keys, Threshold: vs.Threshold}
+}
+
+// applyValidatorChanges advances the set after b: a genesis governs b
+// itself, changes in block N take effect from block N+1. b has already
+// been verified.
+func (core *AurumCore) applyValidatorChanges(b *Block) {
+	if g := validatorGenesisIn(b); g != nil && core.ValidatorSetAt(b.Index) == nil {
+		core.vmu.Lock()
+		core.validatorEpochs = append(core.validatorEpochs, validatorEpoch{From: b.Index, Set: g})
+		core.vmu.Unlock()
+		log.Printf("🔑 Validator genesis at height %d: %d keys, threshold %d", b.Index, len(g.Keys), g.Threshold)
+	}
+	changes := validatorChangesIn(b)
+	if len(changes) == 0 {
+		return
+	}
+	vs := core.ValidatorSetAt(b.Index)
+	if vs == nil {
+		return
+	}
+	for _, change := range changes {
+		next, err := change.Apply(vs)
+		if err != nil {
+			log.Printf("⚠️  Validator change in block #%d ignored: %v", b.Index, err)
+			continue
+		}
+		vs = next
+	}
+	core.vmu.Lock()
+	core.validatorEpochs = append(core.validatorEpochs, validatorEpoch{From: b.Index + 1, Set: vs})
+	core.vmu.Unlock()
+	log.Printf("🔑 Validator set updated at height %d: %d keys, threshold %d", b.Index+1, len(vs.Keys), vs.Threshold)
+}
+
+// validatorGenesisIn returns the set b records as the validator genesis, or
+// nil if it records none (or an invalid one, which verification refuses).
+func validatorGenesisIn(b *Block) *ValidatorSet {
+	for _, tx := range b.Transactions {
+		p, err := tx.Payload()
+		if err != nil {
+			continue
+		}
+		if g, ok := p.(*ValidatorGenesisTx); ok {
+			vs, err := NewValidatorSet(g.Keys, g.Threshold)
+			if err != nil {
+				return nil
+			}
+			return vs
+		}
+	}
+	return nil
+}
+
+// verifyValidatorGenesis checks b records at most one valid genesis, and
+// only while the ledger has no validator set.
+func (core *AurumCore) verifyValidatorGenesis(b *Block) error {
+	n := 0
+	for _, tx := range b.Transactions {
+		p, err := tx.Payload()
+		if err != nil {
+			continue
+		}
+		if g, ok := p.(*ValidatorGenesisTx); ok {
+			if _, err := NewValidatorSet(g.Keys, g.Threshold); err != nil {
+				return fmt.Errorf("block %d: validator genesis: %w", b.Index, err)
+			}
+			n++
+		}
+	}
+	switch {
+	case n == 0:
+		return nil
+	case n > 1:
+		return fmt.Errorf("block %d: %d validator genesis transactions", b.Index, n)
+	case core.ValidatorSetAt(b.Index) != nil:
+		return fmt.Errorf("block %d: validator set is already on the ledger", b.Index)
+	}
+	return nil
+}
+
+// --- Validator Change Transactions ---
+
+const (
+	validatorGenesisType = "validator_genesis"
+	validatorChangeType  = "validator_change"
+)
+
+// ValidatorChange adds, removes or rotates a validator key. It must carry
+// approvals from at least Threshold members of the set it names by SetHash.
+type ValidatorChange struct {
+	Op        string           `json:"op"` // "add", "remove" or "rotate"
+	Key       string           `json:"key"`
+	NewKey    string           `json:"new_key,omitempty"`
+	Threshold int              `json:"threshold,omitempty"` // 0 keeps the current threshold
+	SetHash   string           `json:"set_hash"`
+	Approvals []BlockSignature `json:"approvals"`
+}
+
+// ApprovalMessage is what each approving validator signs.
+func (c *ValidatorChange) ApprovalMessage() []byte {
+	return []byte(fmt.Sprintf("AURUM|validator-change|v1|%s|%s|%s|%s|%d",
+		c.SetHash, c.Op, strings.ToLower(c.Key), strings.ToLower(c.NewKey), c.Threshold))
+}
+
+// Verify checks the change is approved by a quorum of vs.
+func (c *ValidatorChange) Verify(vs *ValidatorSet) error {
+	if c.SetHash != vs.Hash() {
+		return fmt.Errorf("change approved against a different validator set")
+	}
+	msg := c.ApprovalMessage()
+	approved := map[string]bool{}
+	for _, a := range c.Approvals {
+		key := strings.ToLower(a.Pubkey)
+		if vs.Contains(key) && verifySignature(key, a.Signature, msg) {
+			approved[key] = true
+		}
+	}
+	if len(approved) < vs.Threshold {
+		return fmt.Errorf("%d of %d required approvals", len(approved), vs.Threshold)
+	}
+	_, err := c.Apply(vs)
+	return err
+}
+
+// Apply returns the set that results from applying c to vs.
+func (c *ValidatorChange) Apply(vs *ValidatorSet) (*ValidatorSet, error) {
+	key, newKey := strings.ToLower(c.Key), strings.ToLower(c.NewKey)
+	threshold := vs.Threshold
+	if c.Threshold > 0 {
+		threshold = c.Threshold
+	}
+
+	var keys []string
+	switch c.Op {
+	case "add":
+		if vs.Contains(key) {
+			return nil, fmt.Errorf("key %s is already a validator", key)
+		}
+		keys = append(append(keys, vs.Keys...), key)
+	case "remove":
+		if !vs.Contains(key) {
+			return nil, fmt.Errorf("key %s is not a validator", key)
+		}
+		for _, k := range vs.Keys {
+			if k != key {
+				keys = append(keys, k)
+			}
+		}
+		if c.Threshold == 0 && threshold > len(keys) {
+			threshold = len(keys)
+		}
+	case "rotate":
+		if !vs.Contains(key) || vs.Contains(newKey) {
+			return nil, fmt.Errorf("rotate needs an existing key and a new key outside the set")
+		}
+		for _, k := range vs.Keys {
+			if k == key {
+				k = newKey
+			}
+			keys = append(keys, k)
+		}
+	default:
+		return nil, fmt.Errorf("unknown op %q", c.Op)
+	}
+	return NewValidatorSet(keys, threshold)
+}
+
+func validatorChangesIn(b *Block) []ValidatorChange {
+	var changes []ValidatorChange
+	for _, tx := range b.Transactions {
+		p, err := tx.Payload()
+		if err != nil {
+			continue
+		}
+		if v, ok := p.(*ValidatorChangeTx); ok {
+			changes = append(changes, v.Change)
+		}
+	}
+	return changes
+}
+
+// verifyValidatorChanges checks every change in b, applying them in order.
+func verifyValidatorChanges(b *Block, vs *ValidatorSet) error {
+	for i, change := range validatorChangesIn(b) {
+		if err := change.Verify(vs); err != nil {
+			return fmt.Errorf("block %d: validator change %d: %w", b.Index, i, err)
+		}
+		vs, _ = change.Apply(vs)
+	}
+	return nil
+}
+
+// pendingChanges are accepted changes waiting for the next minted block.
+var pendingChanges = struct {
+	sync.Mutex
+	list []ValidatorChange
+}{}
+
+func takePendingChanges() []ValidatorChange {
+	pendingChanges.Lock()
+	defer pendingChanges.Unlock()
+	list := pendingChanges.list
+	pendingChanges.list = nil
+	return list
+}
+
+// pendingChangePayloads re-verifies queued changes against the set the next
+// block will be signed under (earlier changes may have moved it on) and
+// returns the ledger payloads for those that still hold.
+func pendingChangePayloads(height int64) ([]TxPayload, []ValidatorChange) {
+	vs := core.ValidatorSetAt(height)
+	if vs == nil {
+		return nil, nil
+	}
+	var payloads []TxPayload
+	var accepted []ValidatorChange
+	for _, change := range takePendingChanges() {
+		if err := change.Verify(vs); err != nil {
+			log.Printf("⚠️  Dropping validator change %s %s: %v", change.Op, change.Key, err)
+			continue
+		}
+		vs, _ = change.Apply(vs)
+		payloads = append(payloads, &ValidatorChangeTx{Change: change})
+		accepted = append(accepted, change)
+	}
+	return payloads, accepted
+}
+
+// requeueChanges puts changes back after a failed mint.
+func requeueChanges(list []ValidatorChange) {
+	pendingChanges.Lock()
+	defer pendingChanges.Unlock()
+	pendingChanges.list = append(list, pendingChanges.list...)
+}
+
+// handleValidators serves the set in force (optionally ?height=N) and its
+// full history; POST submits an approved change for the next block.
+func handleValidators(w http.ResponseWriter, r *http.Request) {
+	if !core.multiSigner() {
+		http.Error(w, "validator set not configured", http.StatusNotFound)
+		return
+	}
+	if r.Method == http.MethodPost {
+		submitValidatorChange(w, r)
+		return
+	}
+
+	height := core.Height()
+	if raw := r.URL.Query().Get("height"); raw != "" {
+		h, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || h < 0 {
+			http.Error(w, "invalid height", http.StatusBadRequest)
+			return
+		}
+		height = h
+	}
+	vs := core.ValidatorSetAt(height)
+	if vs == nil {
+		http.Error(w, "no validator set on the ledger at this height", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"height":    height,
+		"keys":      vs.Keys,
+		"threshold": vs.Threshold,
+		"set_hash":  vs.Hash(),
+		"history":   core.validatorHistory(),
+	})
+}
+
+func submitValidatorChange(w http.ResponseWriter, r *http.Request) {
+	if config.NodeType == "follower" || (elector != nil && !elector.isLeader()) {
+		http.Error(w, "submit validator changes to the leader", http.StatusMisdirectedRequest)
+		return
+	}
+	var change ValidatorChange
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&change); err != nil {
+		http.Error(w, "bad change", http.StatusBadRequest)
+		return
+	}
+	vs := core.ValidatorSetAt(core.Height())
+	if vs == nil {
+		http.Error(w, "validator genesis is not on the ledger yet", http.StatusConflict)
+		return
+	}
+	if err := change.Verify(vs); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	pendingChanges.Lock()
+	pendingChanges.list = append(pendingChanges.list, change)
+	pendingChanges.Unlock()
+	log.Printf("🔑 Validator change queued: %s %s", change.Op, change.Key)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// --- Co-signing (peer side) ---
+
+// cosignGuard is this validator's double-sign guard, set up in main.
+var cosignGuard *CosignGuard
+
+// CosignGuard remembers the header this validator co-signed at each height
+// it has not finalized yet, and never signs a different one there. It is
+// persisted before a signature leaves, like the remote signer's state, so
+// a restart cannot let a second fork reach quorum.
+type CosignGuard struct {
+	path string
+
+	mu     sync.Mutex
+	signed map[int64]string // height -> block hash
+}
+
+func NewCosignGuard(path string) (*CosignGuard, error) {
+	g := &CosignGuard{path: path, signed: map[int64]string{}}
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	// A damaged guard could let us double-sign; make the operator look at it
+	case json.Unmarshal(data, &g.signed) != nil:
+		return nil, fmt.Errorf("corrupt cosign state %s", path)
+	}
+	return g, nil
+}
+
+// Claim records that b is the header signed at its height, or refuses it
+// if a different one was. Heights below finalized are on our ledger, where
+// nothing is co-signed any more, and are forgotten.
+func (g *CosignGuard) Claim(b *Block, finalized int64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if hash, ok := g.signed[b.Index]; ok {
+		if hash != b.Hash {
+			return fmt.Errorf("already signed a different block at height %d", b.Index)
+		}
+		return nil
+	}
+	next := map[int64]string{b.Index: b.Hash}
+	for height, hash := range g.signed {
+		if height >= finalized {
+			next[height] = hash
+		}
+	}
+	data, _ := json.Marshal(next)
+	if err := writeFileAtomic(g.path, data); err != nil {
+		return fmt.Errorf("persist cosign state: %w", err)
+	}
+	g.signed = next
+	return nil
+}
+
+// handleCosign signs a block proposed by another validator, provided it
+// cleanly extends our own chain tip.
+func handleCosign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	var b Block
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&b); err != nil {
+		http.Error(w, "bad block", http.StatusBadRequest)
+		return
+	}
+	vs := core.validatorSetFor(&b)
+	myKey := hex.EncodeToString(core.publicKey)
+	if vs == nil || !vs.Contains(myKey) {
+		http.Error(w, "this node is not a validator", http.StatusForbidden)
+		return
+	}
+	if core.ValidatorSetAt(b.Index) == nil && !core.isBootstrap(vs) {
+		http.Error(w, "validator genesis does not match our configured set", http.StatusForbidden)
+		return
+	}
+	if !vs.Contains(b.SignerPubkey) || !verifySignature(b.SignerPubkey, b.Signature, signingMessage(&b)) {
+		http.Error(w, "proposer is not a validator", http.StatusForbidden)
+		return
+	}
+
+	tip := core.GetLatest()
+	var prev *Block
+	if tip.Hash != "" {
+		prev = &tip
+	}
+	if err := core.verifyContents(prev, &b); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err := verifyValidatorChanges(&b, vs); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err := cosignGuard.Claim(&b, core.Height()); err != nil {
+		log.Printf("🚨 Cosign: refusing header at height %d: %v", b.Index, err)
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	sig, err := core.signHeader(&b)
+	if err != nil {
+		log.Printf("⚠️  Cosign: %v", err)
+		http.Error(w, "signer unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BlockSignature{Pubkey: myKey, Signature: sig})
+}
+
+// signHeader signs the header commitment of b with this node's key.
+func (core *AurumCore) signHeader(b *Block) (string, error) {
+	sig, err := core.signer.Sign(signingMessage(b))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sig), nil
+}
+
+// --- Co-signing (proposer side) ---
+
+// SignatureCollector is the AurumCore finalizer that gathers peer signatures
+// until the block reaches quorum.
+type SignatureCollector struct {
+	peers  []string
+	client *http.Client
+}
+
+func NewSignatureCollector(cfg Config) (*SignatureCollector, error) {
+	tlsConfig, err := listener.ClientTLS(cfg.ReplicationTLS.CAFile, cfg.ReplicationTLS.CertFile, cfg.ReplicationTLS.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &SignatureCollector{
+		peers:  cfg.Validators.Peers,
+		client: &http.Client{Timeout: cosignTimeout, Transport: &http.Transport{TLSClientConfig: tlsConfig}},
+	}, nil
+}
+
+func (sc *SignatureCollector) Collect(b *Block) error {
+	vs := core.validatorSetFor(b)
+	if vs.countValid(b) >= vs.Threshold {
+		return nil
+	}
+
+	body, _ := json.Marshal(b)
+	ctx, cancel := context.WithTimeout(context.Background(), cosignTimeout)
+	defer cancel()
+	results := make(chan BlockSignature, len(sc.peers))
+	for _, peer := range sc.peers {
+		go func(peer string) {
+			sig, err := sc.request(ctx, peer, body)
+			if err != nil {
+				log.Printf("⚠️  Cosign: %s declined block #%d: %v", peer, b.Index, err)
+			}
+			results <- sig
+		}(peer)
+	}
+
+	msg := signingMessage(b)
+	for range sc.peers {
+		sig := <-results
+		if sig.Pubkey == "" || !vs.Contains(sig.Pubkey) || !verifySignature(sig.Pubkey, sig.Signature, msg) {
+			continue
+		}
+		b.Signatures = append(b.Signatures, sig)
+		if vs.countValid(b) >= vs.Threshold {
+			log.Printf("✍️  Block #%d finalized with %d/%d signatures", b.Index, len(b.Signatures), len(vs.Keys))
+			return nil
+		}
+	}
+	return fmt.Errorf("block %d: quorum not reached (%d of %d)", b.Index, vs.countValid(b), vs.Threshold)
+}
+
+func (sc *SignatureCollector) request(ctx context.Context, peer string, body []byte) (BlockSignature, error) {
+	var sig BlockSignature
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(peer, "/")+"/cosign", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := sc.client.Do(req)
+	if err != nil {
+		return sig, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return sig, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&sig)
+	return sig, err
+}
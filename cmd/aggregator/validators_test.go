@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCosignGuard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain.dat.cosign")
+	guard, err := NewCosignGuard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := &Block{Index: 5, Hash: "aa"}, &Block{Index: 5, Hash: "bb"}
+
+	tests := []struct {
+		name      string
+		block     *Block
+		finalized int64
+		wantErr   bool
+	}{
+		{"first header", a, 5, false},
+		{"same header again", a, 5, false},
+		{"conflicting header", b, 5, true},
+		{"next height", &Block{Index: 6, Hash: "cc"}, 5, false},
+	}
+	for _, tt := range tests {
+		if err := guard.Claim(tt.block, tt.finalized); (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// The guard survives a restart
+	reloaded, err := NewCosignGuard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.Claim(b, 5); err == nil {
+		t.Error("conflicting header signed after a restart")
+	}
+
+	// Heights that reached our ledger are forgotten
+	if err := reloaded.Claim(&Block{Index: 7, Hash: "dd"}, 7); err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.signed) != 1 {
+		t.Errorf("kept %d heights, want only height 7", len(reloaded.signed))
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCosignGuard(path); err == nil {
+		t.Error("corrupt cosign state loaded")
+	}
+}
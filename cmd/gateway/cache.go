@@ -0,0 +1,261 @@
+// cache.go - Block-indexed response cache for the gateway
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMintInterval = 60 * time.Second
+	blockWatchInterval  = 2 * time.Second
+	maxCacheEntries     = 1024
+	maxCachedBodyBytes  = 256 << 10
+)
+
+// cacheablePaths only change when a new block is minted.
+var cacheablePaths = map[string]bool{
+	"/price":   true,
+	"/chain":   true,
+	"/candles": true,
+}
+
+type cacheEntry struct {
+	blockIndex int64
+	status     int
+	header     http.Header
+	body       []byte
+	etag       string
+}
+
+// ResponseCache holds upstream responses for the current block only.
+// Every entry is dropped as soon as the watcher sees a new block.
+type ResponseCache struct {
+	mu           sync.RWMutex
+	entries      map[string]*cacheEntry
+	blockIndex   int64
+	blockTime    int64
+	mintInterval time.Duration
+}
+
+func NewResponseCache(mintInterval time.Duration) *ResponseCache {
+	return &ResponseCache{
+		entries:      make(map[string]*cacheEntry),
+		blockIndex:   -1,
+		mintInterval: mintInterval,
+	}
+}
+
+func cacheKey(r *http.Request, tier string) string {
+	// Query().Encode() sorts parameters, so equivalent URLs share an entry
+	return r.URL.Path + "?" + r.URL.Query().Encode() + "|" + tier
+}
+
+func (c *ResponseCache) current() (int64, int64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.blockIndex, c.blockTime
+}
+
+func (c *ResponseCache) get(key string) (*cacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	if !ok || e.blockIndex != c.blockIndex {
+		return nil, false
+	}
+	return e, true
+}
+
+func (c *ResponseCache) put(key string, e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// A block landed while we were fetching; the response may belong to either
+	if e.blockIndex != c.blockIndex || c.blockIndex < 0 {
+		return
+	}
+	if len(c.entries) >= maxCacheEntries {
+		return
+	}
+	c.entries[key] = e
+}
+
+// observe records the chain tip and invalidates everything on a new block.
+func (c *ResponseCache) observe(index, timestamp int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if index == c.blockIndex {
+		return
+	}
+	c.blockIndex = index
+	c.blockTime = timestamp
+	c.entries = make(map[string]*cacheEntry)
+	log.Printf("🧊 Cache: new block #%d observed, entries invalidated", index)
+}
+
+// maxAge is how long a response stays fresh: the time left until the next mint.
+func (c *ResponseCache) maxAge() int {
+	_, blockTime := c.current()
+	if blockTime == 0 {
+		return 0
+	}
+	next := time.Unix(blockTime, 0).Add(c.mintInterval)
+	remaining := time.Until(next)
+	if remaining < 0 {
+		return 0
+	}
+	if remaining > c.mintInterval {
+		remaining = c.mintInterval
+	}
+	return int(remaining.Seconds())
+}
+
+// --- Block Watcher ---
+
+// StartBlockWatcher polls the chain tip through the upstream pool.
+func (c *ResponseCache) StartBlockWatcher(pool *UpstreamPool) {
+	go func() {
+		ticker := time.NewTicker(blockWatchInterval)
+		for {
+			if index, ts, err := fetchChainTip(pool); err == nil {
+				c.observe(index, ts)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func fetchChainTip(pool *UpstreamPool) (int64, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://upstream/chain", nil)
+	resp, err := pool.RoundTrip(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var status struct {
+		Height          int64 `json:"height"`
+		LatestTimestamp int64 `json:"latest_timestamp"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return 0, 0, err
+	}
+	if status.Height == 0 {
+		return 0, 0, fmt.Errorf("empty chain")
+	}
+	return status.Height - 1, status.LatestTimestamp, nil
+}
+
+// --- HTTP ---
+
+// bufferedResponse captures a proxied response so it can be cached.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// Serve answers from cache when possible, otherwise proxies and stores the result.
+func (c *ResponseCache) Serve(w http.ResponseWriter, r *http.Request, tier string, next http.Handler) {
+	if r.Method != http.MethodGet || !cacheablePaths[r.URL.Path] {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	key := cacheKey(r, tier)
+	entry, hit := c.get(key)
+	if !hit {
+		index, _ := c.current()
+		rec := &bufferedResponse{header: make(http.Header)}
+		next.ServeHTTP(rec, r)
+
+		entry = &cacheEntry{
+			blockIndex: index,
+			status:     rec.status,
+			header:     rec.header,
+			body:       rec.body.Bytes(),
+		}
+		if rec.status == http.StatusOK && rec.body.Len() <= maxCachedBodyBytes {
+			sum := sha256.Sum256(entry.body)
+			entry.etag = fmt.Sprintf(`"%d-%s"`, index, hex.EncodeToString(sum[:8]))
+			c.put(key, entry)
+		}
+	}
+
+	for k, vals := range entry.header {
+		w.Header()[k] = vals
+	}
+	if hit {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+
+	if entry.etag == "" {
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(entry.status)
+		w.Write(entry.body)
+		return
+	}
+
+	w.Header().Set("ETag", entry.etag)
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(c.maxAge()))
+	if etagMatches(r.Header.Get("If-None-Match"), entry.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(entry.body)))
+	w.WriteHeader(entry.status)
+	io.Copy(w, bytes.NewReader(entry.body))
+}
+
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func mintIntervalFromEnv() time.Duration {
+	if v := os.Getenv("MINT_INTERVAL_SECONDS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return defaultMintInterval
+}
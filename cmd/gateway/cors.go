@@ -0,0 +1,105 @@
+// cors.go - Per-key CORS policy
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Only what the gateway actually serves is advertised to browsers.
+var (
+	corsAllowedMethods = []string{"GET", "HEAD", "OPTIONS"}
+	corsAllowedHeaders = []string{"Content-Type", "X-API-Key", "If-None-Match", headerKeyID, headerTimestamp, headerNonce, headerSignature}
+	corsExposedHeaders = []string{"ETag", "X-Cache", "X-Client", "X-Tier"}
+)
+
+const corsMaxAge = "600"
+
+// originAllowed reports whether a browser origin may use the given key.
+// An empty list means the key is for server-to-server use only.
+func (k *APIKey) originAllowed(origin string) bool {
+	for _, allowed := range k.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyKeyAllowsOrigin is used for preflights, which browsers send without credentials.
+func anyKeyAllowsOrigin(origin string) bool {
+	for _, info := range validAPIKeys {
+		if info.originAllowed(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// handlePreflight answers an OPTIONS preflight. Anything outside the
+// advertised methods/headers or from an unknown origin is refused.
+func handlePreflight(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	if origin == "" || !anyKeyAllowsOrigin(origin) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	if method := r.Header.Get("Access-Control-Request-Method"); !containsFold(corsAllowedMethods, method) {
+		http.Error(w, "method not allowed", http.StatusForbidden)
+		return
+	}
+	if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		for _, header := range strings.Split(requested, ",") {
+			if !containsFold(corsAllowedHeaders, header) {
+				http.Error(w, "header not allowed", http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	h.Set("Access-Control-Allow-Origin", origin)
+	h.Set("Access-Control-Allow-Methods", strings.Join(corsAllowedMethods, ", "))
+	h.Set("Access-Control-Allow-Headers", strings.Join(corsAllowedHeaders, ", "))
+	h.Set("Access-Control-Max-Age", corsMaxAge)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyCORS checks the request origin against the authenticated key.
+// Requests without an Origin header are not from a browser and pass through.
+func applyCORS(w http.ResponseWriter, r *http.Request, key *APIKey) bool {
+	origin := r.Header.Get("Origin")
+	w.Header().Add("Vary", "Origin")
+	if origin == "" {
+		return true
+	}
+	if !key.originAllowed(origin) {
+		http.Error(w, "origin not allowed for this API key", http.StatusForbidden)
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+	return true
+}
+
+// allowErrorOrigin lets a browser read an authentication error when some
+// key accepts its origin. The error carries no data, only the reason.
+func allowErrorOrigin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Origin")
+	if origin := r.Header.Get("Origin"); origin != "" && anyKeyAllowsOrigin(origin) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+}
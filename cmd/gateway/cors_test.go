@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// withUpstream points the proxy and cache at a stub aggregator.
+func withUpstream(t *testing.T) {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(upstream.Close)
+	pool, err := NewUpstreamPool([]string{upstream.URL}, time.Second, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedProxy, savedCache := proxy, cache
+	proxy, cache = NewReverseProxy(pool), NewResponseCache(time.Minute)
+	t.Cleanup(func() { proxy, cache = savedProxy, savedCache })
+}
+
+func TestPreflight(t *testing.T) {
+	withKeys(t, map[string]APIKey{
+		"app":    {Key: "app", AllowedOrigins: []string{"https://app.example"}},
+		"server": {Key: "server"},
+	})
+	tests := []struct {
+		name, origin, method, headers string
+		want                          int
+	}{
+		{"allowed", "https://app.example", "GET", "X-API-Key, If-None-Match", http.StatusNoContent},
+		{"origin case-insensitive", "https://APP.example", "GET", "", http.StatusNoContent},
+		{"unknown origin", "https://evil.example", "GET", "", http.StatusForbidden},
+		{"no origin", "", "GET", "", http.StatusForbidden},
+		{"method not offered", "https://app.example", "POST", "", http.StatusForbidden},
+		{"header not offered", "https://app.example", "GET", "Authorization", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodOptions, "/price", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		r.Header.Set("Access-Control-Request-Method", tt.method)
+		if tt.headers != "" {
+			r.Header.Set("Access-Control-Request-Headers", tt.headers)
+		}
+		w := httptest.NewRecorder()
+		proxyHandler(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.want)
+		}
+		allowed := w.Header().Get("Access-Control-Allow-Origin")
+		if (tt.want == http.StatusNoContent) != (allowed == tt.origin && allowed != "") {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q", tt.name, allowed)
+		}
+	}
+}
+
+func TestPerKeyOrigins(t *testing.T) {
+	withUpstream(t)
+	withKeys(t, map[string]APIKey{
+		"app":    {Key: "app", RateLimit: 2, Tier: "paid", AllowedOrigins: []string{"https://app.example"}},
+		"any":    {Key: "any", RateLimit: 100, Tier: "paid", AllowedOrigins: []string{"*"}},
+		"server": {Key: "server", RateLimit: 100, Tier: "paid"},
+	})
+	request := func(key, origin string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/chain", nil)
+		r.Header.Set("X-API-Key", key)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		proxyHandler(w, r)
+		return w
+	}
+
+	tests := []struct {
+		name, key, origin string
+		want              int
+		wantAllowOrigin   string
+	}{
+		{"listed origin", "app", "https://app.example", http.StatusOK, "https://app.example"},
+		{"origin of another key", "app", "https://other.example", http.StatusForbidden, ""},
+		{"wildcard key", "any", "https://other.example", http.StatusOK, "https://other.example"},
+		{"server-only key from a browser", "server", "https://app.example", http.StatusForbidden, ""},
+		{"server-only key without origin", "server", "", http.StatusOK, ""},
+		// Errors are readable by any origin some key accepts, here through "any"
+		{"bad key from a browser", "nope", "https://other.example", http.StatusUnauthorized, "https://other.example"},
+	}
+	for _, tt := range tests {
+		w := request(tt.key, tt.origin)
+		if w.Code != tt.want || w.Header().Get("Access-Control-Allow-Origin") != tt.wantAllowOrigin {
+			t.Errorf("%s: status %d, allow origin %q; want %d, %q", tt.name, w.Code, w.Header().Get("Access-Control-Allow-Origin"), tt.want, tt.wantAllowOrigin)
+		}
+	}
+}
+
+func TestRefusedOriginDoesNotChargeRateLimit(t *testing.T) {
+	withUpstream(t)
+	withKeys(t, map[string]APIKey{
+		"limited": {Key: "limited", RateLimit: 1, Tier: "paid", AllowedOrigins: []string{"https://app.example"}},
+	})
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/chain", nil)
+		r.Header.Set("X-API-Key", "limited")
+		r.Header.Set("Origin", "https://evil.example")
+		w := httptest.NewRecorder()
+		proxyHandler(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("refused origin: status %d", w.Code)
+		}
+	}
+	r := httptest.NewRequest(http.MethodGet, "/chain", nil)
+	r.Header.Set("X-API-Key", "limited")
+	w := httptest.NewRecorder()
+	proxyHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("first allowed request: status %d %s", w.Code, w.Body)
+	}
+
+	// No key accepts this origin, so its 401 stays unreadable
+	r = httptest.NewRequest(http.MethodGet, "/chain", nil)
+	r.Header.Set("X-API-Key", "nope")
+	r.Header.Set("Origin", "https://evil.example")
+	w = httptest.NewRecorder()
+	proxyHandler(w, r)
+	if w.Code != http.StatusUnauthorized || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("unknown origin: status %d, allow origin %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
@@ -1,14 +1,18 @@
-// api_gateway.go - Tiered Access Gateway with Hardcoded Port
+// api_gateway.go - Tiered Access Gateway
 package main
 
 import (
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"net/http/httputil"
 	"os"
+	"strings"
 	"sync"
 	"time"
+
+	"aurum-oracle/pkg/listener"
+	"aurum-oracle/pkg/units"
 )
 
 type APIKey struct {
@@ -16,6 +20,13 @@ type APIKey struct {
 	ClientName string
 	RateLimit  int 
 	Tier       string // "free" or "paid"
+	// ClientCertCN maps a verified mTLS client certificate onto this key
+	ClientCertCN string
+	// AllowedOrigins lists browser origins that may use this key ("*" for any)
+	AllowedOrigins []string
+	// SigningKeyID/SigningSecret enable HMAC-signed requests (see signing.go)
+	SigningKeyID  string
+	SigningSecret string
 }
 
 type RateLimiter struct {
@@ -26,17 +37,27 @@ type RateLimiter struct {
 var (
 	validAPIKeys = map[string]APIKey{
 		// FREE TIER (Delayed Data)
-		"YOUR_DEMO_KEY": {Key: "YOUR_DEMO_KEY", ClientName: "Free User", RateLimit: 60, Tier: "free"},
+		"YOUR_DEMO_KEY": {Key: "YOUR_DEMO_KEY", ClientName: "Free User", RateLimit: 60, Tier: "free", AllowedOrigins: []string{"https://YOUR_APP_DOMAIN"}},
 		
 		// PAID TIER (Real-Time)
 		"YOUR_PAID_KEY": {Key: "YOUR_PAID_KEY", ClientName: "Paid User A", RateLimit: 1000, Tier: "paid"},
+
+		// INSTITUTIONAL (mTLS Only - no static key; the CA is TLS_CLIENT_CA_FILE)
+		"YOUR_MTLS_CLIENT": {ClientName: "Institution C", RateLimit: 1000, Tier: "paid", ClientCertCN: "YOUR_CLIENT_CERT_CN"},
+
+		// INSTITUTIONAL (Signed Requests Only - no static key)
+		"YOUR_SIGNING_KEY_ID": {ClientName: "Institution B", RateLimit: 1000, Tier: "paid", SigningKeyID: "YOUR_SIGNING_KEY_ID", SigningSecret: "YOUR_SIGNING_SECRET"},
 	}
 	rateLimiter = &RateLimiter{
 		requests: make(map[string][]time.Time),
 	}
-	aggregatorURL = "http://localhost:9000"
+	proxy *httputil.ReverseProxy
+	cache *ResponseCache
 )
 
+const defaultAggregatorURL = "http://localhost:9000"
+
+
 // --- Rate Limiter ---
 func (rl *RateLimiter) StartCleanupService() {
 	go func() {
@@ -83,62 +104,135 @@ func (rl *RateLimiter) Allow(apiKey string, limit int) bool {
 
 // --- Handlers ---
 
-func authenticate(r *http.Request) (*APIKey, error) {
+// bindClientCertCNs reads CLIENT_CERT_CNS, "cn=key,cn=key", binding
+// certificate CNs to entries of validAPIKeys without editing the table.
+func bindClientCertCNs() error {
+	list := os.Getenv("CLIENT_CERT_CNS")
+	if list == "" { return nil }
+	for _, pair := range strings.Split(list, ",") {
+		cn, key, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		info, known := validAPIKeys[key]
+		if !ok || cn == "" || !known { return fmt.Errorf("CLIENT_CERT_CNS: invalid binding %q", pair) }
+		info.ClientCertCN = cn
+		validAPIKeys[key] = info
+	}
+	return nil
+}
+
+// keyForCertificate finds the API key bound to a verified client certificate.
+func keyForCertificate(r *http.Request) (string, bool) {
+	cn, ok := listener.PeerCommonName(r)
+	if !ok || cn == "" { return "", false }
+	for key, info := range validAPIKeys {
+		if info.ClientCertCN == cn { return key, true }
+	}
+	return "", false
+}
+
+// identify finds the key a request is made with and the rate limit bucket
+// it draws from. Nothing is charged yet: the origin is checked first.
+func identify(r *http.Request) (*APIKey, string, error) {
+	if key, ok := keyForCertificate(r); ok {
+		keyInfo := validAPIKeys[key]
+		return &keyInfo, key, nil
+	}
+	if isSignedRequest(r) {
+		keyInfo, err := verifySignedRequest(r)
+		if err != nil { return nil, "", err }
+		return keyInfo, "hmac:" + keyInfo.SigningKeyID, nil
+	}
+
 	key := r.Header.Get("X-API-Key")
 	if key == "" { key = r.URL.Query().Get("api_key") }
 	keyInfo, ok := validAPIKeys[key]
-	if !ok { return nil, fmt.Errorf("invalid API key") }
-	if !rateLimiter.Allow(key, keyInfo.RateLimit) { return nil, fmt.Errorf("rate limit exceeded") }
-	return &keyInfo, nil
-}
-
-func enableCORS(w *http.ResponseWriter) {
-	(*w).Header().Set("Access-Control-Allow-Origin", "*")
-	(*w).Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	(*w).Header().Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key")
+	// Signing-only identities have no static key and must never match here
+	if !ok || keyInfo.Key == "" { return nil, "", fmt.Errorf("invalid API key") }
+	return &keyInfo, key, nil
 }
 
 func proxyHandler(w http.ResponseWriter, r *http.Request) {
-	enableCORS(&w)
-	if r.Method == "OPTIONS" { w.WriteHeader(http.StatusOK); return }
+	if !routeAllowed(w, r) { return }
+	if r.Method == http.MethodOptions { handlePreflight(w, r); return }
+
+	// The body is buffered (signatures cover it, failover may replay it)
+	// only once the caller is known: signed requests after a header check,
+	// everyone else after authenticating
+	signed := isSignedRequest(r)
+	if signed {
+		if _, err := checkSignatureHeaders(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err := bufferBody(r); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+	}
 
-	clientInfo, err := authenticate(r)
+	clientInfo, bucket, err := identify(r)
 	if err != nil {
+		allowErrorOrigin(w, r)
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
+	// A disallowed origin is refused before it can spend the key's budget
+	if !applyCORS(w, r, clientInfo) { return }
+	if !rateLimiter.Allow(bucket, clientInfo.RateLimit) {
+		http.Error(w, "rate limit exceeded", http.StatusUnauthorized)
+		return
+	}
+	if !signed {
+		if err := bufferBody(r); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+	}
 	
 	// --- TIER ENFORCEMENT LOGIC ---
-	targetURL := aggregatorURL + r.URL.Path
-	
-	// If Free Tier, force delayed parameter
-	if clientInfo.Tier == "free" && r.URL.Path == "/price" {
-		targetURL += "?delayed=true"
+	// Never forward the credential, and force delayed data for the Free Tier
+	query := r.URL.Query()
+	query.Del("api_key")
+	if clientInfo.Tier == "free" && (r.URL.Path == "/price" || r.URL.Path == "/candles") {
+		query.Set("delayed", "true")
 	}
-	
-	// Proxy
-	resp, err := http.Get(targetURL)
-	if err != nil {
-		http.Error(w, "Oracle Consensus Unavailable", http.StatusBadGateway)
-		return
+	// Canonical unit names keep "gram" and "g" in one cache entry
+	if r.URL.Path == "/price" && query.Has("unit") {
+		unit, err := units.Parse(query.Get("unit"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query.Set("unit", unit.Name)
 	}
-	defer resp.Body.Close()
-	
+	r.URL.RawQuery = query.Encode()
+
 	w.Header().Set("X-Client", clientInfo.ClientName)
 	w.Header().Set("X-Tier", clientInfo.Tier)
-	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
-	w.WriteHeader(resp.StatusCode)
-	io.Copy(w, resp.Body)
+	cache.Serve(w, r, clientInfo.Tier, proxy)
 }
 
 func main() {
-	if url := os.Getenv("AGGREGATOR_URL"); url != "" { aggregatorURL = url }
+	if err := bindClientCertCNs(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	upstreamTLS, err := listener.ClientTLS(os.Getenv("UPSTREAM_CA_FILE"), os.Getenv("UPSTREAM_CERT_FILE"), os.Getenv("UPSTREAM_KEY_FILE"))
+	if err != nil {
+		log.Fatalf("❌ Upstream TLS config: %v", err)
+	}
+	pool, err := NewUpstreamPool(upstreamsFromEnv(), upstreamTimeoutFromEnv(), upstreamTLS)
+	if err != nil {
+		log.Fatalf("❌ Upstream config: %v", err)
+	}
+	pool.StartHealthChecks()
+	proxy = NewReverseProxy(pool)
+	cache = NewResponseCache(mintIntervalFromEnv())
+	cache.StartBlockWatcher(pool)
 	
-	// HARDCODED PORT 3000 (Critical Fix)
-	port := "3000"
+	listenCfg := listener.FromEnv(":3000")
 	
 	rateLimiter.StartCleanupService()
+	nonceCache.StartCleanupService()
 	http.HandleFunc("/", proxyHandler)
-	log.Printf("🛡️  AURUM API Gateway (Tiered) Active on port :%s", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	log.Printf("🛡️  AURUM API Gateway (Tiered) Active on %s (tls=%v)", listenCfg.Addr, listenCfg.TLSEnabled())
+	log.Fatal(listener.ListenAndServe(listenCfg, nil))
 }
\ No newline at end of file
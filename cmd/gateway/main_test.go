@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withKeys swaps validAPIKeys, with a fresh rate limiter, for the duration
+// of a test.
+func withKeys(t *testing.T, keys map[string]APIKey) {
+	t.Helper()
+	savedKeys, savedLimiter := validAPIKeys, rateLimiter
+	validAPIKeys, rateLimiter = keys, &RateLimiter{requests: make(map[string][]time.Time)}
+	t.Cleanup(func() { validAPIKeys, rateLimiter = savedKeys, savedLimiter })
+}
+
+// verifiedCert is a self-signed certificate for cn, presented as the
+// verified chain a TLS listener would attach to the request.
+func verifiedCert(t *testing.T, cn string) *tls.ConnectionState {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}, VerifiedChains: [][]*x509.Certificate{{cert}}}
+}
+
+func TestIdentifyClientCertificate(t *testing.T) {
+	withKeys(t, map[string]APIKey{
+		"mtls-client": {ClientName: "Institution C", RateLimit: 100, Tier: "paid", ClientCertCN: "institution-c"},
+		"static-key":  {Key: "static-key", ClientName: "Free User", RateLimit: 100, Tier: "free"},
+	})
+	unverified := verifiedCert(t, "institution-c")
+	unverified.VerifiedChains = nil
+
+	tests := []struct {
+		name       string
+		tls        *tls.ConnectionState
+		apiKey     string
+		wantClient string
+		wantErr    string
+	}{
+		{"matching CN", verifiedCert(t, "institution-c"), "", "Institution C", ""},
+		{"unknown CN", verifiedCert(t, "someone-else"), "", "", "invalid API key"},
+		{"unknown CN falls back to the API key", verifiedCert(t, "someone-else"), "static-key", "Free User", ""},
+		{"no certificate", nil, "", "", "invalid API key"},
+		{"unverified certificate", unverified, "", "", "invalid API key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/price", nil)
+			r.TLS = tt.tls
+			if tt.apiKey != "" {
+				r.Header.Set("X-API-Key", tt.apiKey)
+			}
+			info, _, err := identify(r)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || info.ClientName != tt.wantClient {
+				t.Fatalf("got %+v, %v; want %s", info, err, tt.wantClient)
+			}
+		})
+	}
+}
+
+func TestBindClientCertCNs(t *testing.T) {
+	tests := []struct {
+		env     string
+		wantErr bool
+	}{
+		{"", false},
+		{"institution-a=paid", false},
+		{" institution-a=paid , institution-b=free ", false},
+		{"institution-a=unknown", true},
+		{"=paid", true},
+		{"institution-a", true},
+	}
+	for _, tt := range tests {
+		withKeys(t, map[string]APIKey{"paid": {Key: "paid"}, "free": {Key: "free"}})
+		t.Setenv("CLIENT_CERT_CNS", tt.env)
+		if err := bindClientCertCNs(); (err != nil) != tt.wantErr {
+			t.Errorf("CLIENT_CERT_CNS=%q: error = %v, want error %v", tt.env, err, tt.wantErr)
+		}
+	}
+	withKeys(t, map[string]APIKey{"paid": {Key: "paid", ClientName: "Paid"}})
+	t.Setenv("CLIENT_CERT_CNS", "institution-a=paid")
+	if err := bindClientCertCNs(); err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("GET", "/price", nil)
+	r.TLS = verifiedCert(t, "institution-a")
+	if key, ok := keyForCertificate(r); !ok || key != "paid" {
+		t.Errorf("bound CN maps to %q, %v", key, ok)
+	}
+}
@@ -0,0 +1,320 @@
+// proxy.go - Pooled reverse proxy with health-checked aggregator failover
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultUpstreamTimeout = 10 * time.Second
+	healthCheckInterval    = 10 * time.Second
+	healthCheckTimeout     = 3 * time.Second
+	maxProxyBodyBytes      = 1 << 20
+)
+
+// Upstream is a single aggregator the gateway can forward to.
+type Upstream struct {
+	URL *url.URL
+
+	mu      sync.RWMutex
+	healthy bool
+	lastErr error
+	checked time.Time
+}
+
+func (u *Upstream) Healthy() bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.healthy
+}
+
+func (u *Upstream) setHealth(ok bool, err error) {
+	u.mu.Lock()
+	changed := u.healthy != ok
+	u.healthy = ok
+	u.lastErr = err
+	u.checked = time.Now()
+	u.mu.Unlock()
+
+	if changed && ok {
+		log.Printf("🟢 Upstream %s is UP", u.URL)
+	} else if changed {
+		log.Printf("🔴 Upstream %s is DOWN: %v", u.URL, err)
+	}
+}
+
+// UpstreamPool prefers the first healthy upstream in configured order
+// (primary first) and fails over down the list.
+type UpstreamPool struct {
+	upstreams []*Upstream
+	transport *http.Transport
+	timeout   time.Duration
+}
+
+func NewUpstreamPool(rawURLs []string, timeout time.Duration, tlsConfig *tls.Config) (*UpstreamPool, error) {
+	pool := &UpstreamPool{
+		timeout: timeout,
+		transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   3 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   32,
+			IdleConnTimeout:       90 * time.Second,
+			TLSClientConfig:       tlsConfig,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: timeout,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+	}
+	for _, raw := range rawURLs {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid upstream %q", raw)
+		}
+		// Assume healthy until the first probe says otherwise
+		pool.upstreams = append(pool.upstreams, &Upstream{URL: u, healthy: true})
+	}
+	if len(pool.upstreams) == 0 {
+		return nil, fmt.Errorf("no upstreams configured")
+	}
+	return pool, nil
+}
+
+// candidates returns healthy upstreams first, then unhealthy ones as a last resort.
+func (p *UpstreamPool) candidates() []*Upstream {
+	var up, down []*Upstream
+	for _, u := range p.upstreams {
+		if u.Healthy() {
+			up = append(up, u)
+		} else {
+			down = append(down, u)
+		}
+	}
+	return append(up, down...)
+}
+
+// RoundTrip implements http.RoundTripper. Connection-level failures mark the
+// upstream down; GET and HEAD, and requests that could not connect, are
+// retried against the next candidate.
+func (p *UpstreamPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var lastErr error
+	for _, u := range p.candidates() {
+		if req.Context().Err() != nil {
+			return nil, req.Context().Err()
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context(), p.timeout)
+		out := req.Clone(ctx)
+		out.URL.Scheme = u.URL.Scheme
+		out.URL.Host = u.URL.Host
+		out.URL.Path = strings.TrimSuffix(u.URL.Path, "/") + req.URL.Path
+		out.URL.RawPath = ""
+		out.Host = u.URL.Host
+		if req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			out.Body = body
+		}
+
+		resp, err := p.transport.RoundTrip(out)
+		if err != nil {
+			cancel()
+			if req.Context().Err() != nil {
+				// The client went away; that says nothing about the upstream
+				return nil, err
+			}
+			u.setHealth(false, err)
+			lastErr = err
+			if !retryable(req, err) {
+				return nil, err
+			}
+			log.Printf("⚠️  Upstream %s failed, trying next: %v", u.URL, err)
+			continue
+		}
+		// The deadline must outlive RoundTrip so the body can still be streamed
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no upstream available")
+	}
+	return nil, lastErr
+}
+
+// retryable reports whether a failed request may be sent to another
+// upstream: reads always, anything else only if it never left this host.
+func retryable(req *http.Request, err error) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// --- Health Checking ---
+
+func (p *UpstreamPool) StartHealthChecks() {
+	go func() {
+		p.checkAll()
+		ticker := time.NewTicker(healthCheckInterval)
+		for range ticker.C {
+			p.checkAll()
+		}
+	}()
+}
+
+func (p *UpstreamPool) checkAll() {
+	var wg sync.WaitGroup
+	for _, u := range p.upstreams {
+		wg.Add(1)
+		go func(u *Upstream) {
+			defer wg.Done()
+			u.setHealth(p.probe(u))
+		}(u)
+	}
+	wg.Wait()
+}
+
+func (p *UpstreamPool) probe(u *Upstream) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(u.URL.String(), "/")+"/chain", nil)
+	resp, err := p.transport.RoundTrip(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return true, nil
+}
+
+// --- Reverse Proxy ---
+
+// publicPaths are the aggregator endpoints served through the gateway, all
+// read-only. Co-signing, validator changes, the follower block stream and
+// node health stay internal.
+var publicPaths = map[string]bool{
+	"/price":   true,
+	"/chain":   true,
+	"/blocks":  true,
+	"/spread":  true,
+	"/candles": true,
+}
+
+// routeAllowed answers 404 for paths the gateway does not serve and 405 for
+// anything but reads. Preflights (OPTIONS) are left to the CORS handler.
+func routeAllowed(w http.ResponseWriter, r *http.Request) bool {
+	if !publicPaths[r.URL.Path] {
+		http.NotFound(w, r)
+		return false
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	w.Header().Set("Allow", strings.Join(corsAllowedMethods, ", "))
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	return false
+}
+
+// NewReverseProxy builds the streaming proxy that forwards through the pool.
+// Headers and query survive untouched apart from credential stripping; only
+// requests that passed routeAllowed reach it.
+func NewReverseProxy(pool *UpstreamPool) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			// Placeholder target; the pool picks the real upstream per attempt
+			pr.Out.URL.Scheme = pool.upstreams[0].URL.Scheme
+			pr.Out.URL.Host = pool.upstreams[0].URL.Host
+			pr.Out.Header.Del("X-API-Key")
+			pr.Out.Header.Del(headerSignature)
+			pr.SetXForwarded()
+		},
+		Transport:     pool,
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("❌ Proxy error for %s: %v", r.URL.Path, err)
+			http.Error(w, "Oracle Consensus Unavailable", http.StatusBadGateway)
+		},
+	}
+}
+
+// bufferBody makes the request body replayable so failover can resend it.
+func bufferBody(r *http.Request) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxProxyBodyBytes+1))
+	r.Body.Close()
+	if err != nil {
+		return err
+	}
+	if len(data) > maxProxyBodyBytes {
+		return fmt.Errorf("request body too large")
+	}
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(string(data))), nil
+	}
+	r.Body, _ = r.GetBody()
+	r.ContentLength = int64(len(data))
+	return nil
+}
+
+// --- Config ---
+
+func upstreamsFromEnv() []string {
+	if urls := os.Getenv("AGGREGATOR_URLS"); urls != "" {
+		return strings.Split(urls, ",")
+	}
+	if url := os.Getenv("AGGREGATOR_URL"); url != "" {
+		return []string{url}
+	}
+	return []string{defaultAggregatorURL}
+}
+
+func upstreamTimeoutFromEnv() time.Duration {
+	if v := os.Getenv("UPSTREAM_TIMEOUT_SECONDS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return defaultUpstreamTimeout
+}
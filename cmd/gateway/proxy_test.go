@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRouteAllowed(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/price", http.StatusOK},
+		{http.MethodHead, "/candles", http.StatusOK},
+		{http.MethodOptions, "/chain", http.StatusOK},
+		{http.MethodPost, "/price", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/cosign", http.StatusNotFound},
+		{http.MethodGet, "/validators", http.StatusNotFound},
+		{http.MethodGet, "/blocks/stream", http.StatusNotFound},
+		{http.MethodGet, "/nodes", http.StatusNotFound},
+		{http.MethodGet, "/", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		ok := routeAllowed(w, httptest.NewRequest(tt.method, tt.path, nil))
+		got := w.Code
+		if ok {
+			got = http.StatusOK
+		}
+		if got != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRoundTripFailover(t *testing.T) {
+	var hits atomic.Int32
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits.Add(1) }))
+	defer up.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	pool, err := NewUpstreamPool([]string{down.URL, up.URL}, time.Second, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/price", nil)
+	resp, err := pool.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if hits.Load() != 1 || pool.upstreams[0].Healthy() || !pool.upstreams[1].Healthy() {
+		t.Errorf("hits %d, primary healthy %v, secondary healthy %v", hits.Load(), pool.upstreams[0].Healthy(), pool.upstreams[1].Healthy())
+	}
+}
+
+func TestRoundTripClientAbortKeepsUpstreamHealthy(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-release }))
+	defer slow.Close()
+	defer close(release)
+
+	pool, err := NewUpstreamPool([]string{slow.URL}, 5*time.Second, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/price", nil).WithContext(ctx)
+	if _, err := pool.RoundTrip(req); err == nil {
+		t.Fatal("aborted request succeeded")
+	}
+	if !pool.upstreams[0].Healthy() {
+		t.Error("a client abort marked the upstream down")
+	}
+}
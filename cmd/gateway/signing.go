@@ -0,0 +1,159 @@
+// signing.go - HMAC-SHA256 signed requests for institutional clients
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	headerKeyID     = "X-Aurum-Key-Id"
+	headerTimestamp = "X-Aurum-Timestamp"
+	headerNonce     = "X-Aurum-Nonce"
+	headerSignature = "X-Aurum-Signature"
+
+	defaultMaxClockSkew = 5 * time.Minute
+	minNonceLength      = 16
+	maxNonceLength      = 128
+)
+
+// NonceCache remembers nonces for as long as their timestamp could still
+// pass the skew check, which is all that is needed to block replays.
+type NonceCache struct {
+	mu     sync.Mutex
+	seen   map[string]time.Time
+	window time.Duration
+}
+
+func NewNonceCache(maxSkew time.Duration) *NonceCache {
+	return &NonceCache{seen: make(map[string]time.Time), window: 2 * maxSkew}
+}
+
+// Use records a nonce and reports false if it was already used.
+func (nc *NonceCache) Use(keyID, nonce string, now time.Time) bool {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	id := keyID + ":" + nonce
+	if expiry, ok := nc.seen[id]; ok && now.Before(expiry) {
+		return false
+	}
+	nc.seen[id] = now.Add(nc.window)
+	return true
+}
+
+func (nc *NonceCache) StartCleanupService() {
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		for range ticker.C {
+			nc.mu.Lock()
+			now := time.Now()
+			for id, expiry := range nc.seen {
+				if now.After(expiry) { delete(nc.seen, id) }
+			}
+			nc.mu.Unlock()
+		}
+	}()
+}
+
+var (
+	maxClockSkew = maxClockSkewFromEnv()
+	nonceCache   = NewNonceCache(maxClockSkew)
+)
+
+func isSignedRequest(r *http.Request) bool {
+	return r.Header.Get(headerSignature) != ""
+}
+
+// StringToSign is the canonical request that clients must sign:
+//
+//	METHOD \n PATH \n SORTED_QUERY \n TIMESTAMP \n NONCE \n hex(sha256(BODY))
+func StringToSign(method, path, query, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		query,
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+}
+
+func keyForSigningID(keyID string) (APIKey, bool) {
+	for _, info := range validAPIKeys {
+		if info.SigningKeyID != "" && info.SigningKeyID == keyID { return info, true }
+	}
+	return APIKey{}, false
+}
+
+// checkSignatureHeaders validates everything about a signed request that
+// does not need the body: key ID, timestamp and nonce format. The gateway
+// runs it before reading the body, so unknown callers cannot make it buffer.
+func checkSignatureHeaders(r *http.Request) (APIKey, error) {
+	keyID := r.Header.Get(headerKeyID)
+	timestamp := r.Header.Get(headerTimestamp)
+	nonce := r.Header.Get(headerNonce)
+	if keyID == "" || timestamp == "" || nonce == "" {
+		return APIKey{}, fmt.Errorf("incomplete signature headers")
+	}
+
+	keyInfo, ok := keyForSigningID(keyID)
+	if !ok || keyInfo.SigningSecret == "" { return APIKey{}, fmt.Errorf("invalid signing key") }
+
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil { return APIKey{}, fmt.Errorf("invalid timestamp") }
+	skew := time.Since(time.Unix(secs, 0))
+	if skew < -maxClockSkew || skew > maxClockSkew { return APIKey{}, fmt.Errorf("timestamp outside allowed clock skew") }
+
+	if len(nonce) < minNonceLength || len(nonce) > maxNonceLength { return APIKey{}, fmt.Errorf("invalid nonce") }
+	return keyInfo, nil
+}
+
+// verifySignedRequest authenticates a request signed with a key's HMAC secret.
+// The body must already be replayable (see bufferBody). The caller charges
+// the rate limit.
+func verifySignedRequest(r *http.Request) (*APIKey, error) {
+	keyInfo, err := checkSignatureHeaders(r)
+	if err != nil { return nil, err }
+	keyID := r.Header.Get(headerKeyID)
+	timestamp := r.Header.Get(headerTimestamp)
+	nonce := r.Header.Get(headerNonce)
+	signature := r.Header.Get(headerSignature)
+	now := time.Now()
+
+	var body []byte
+	if r.GetBody != nil {
+		rc, err := r.GetBody()
+		if err != nil { return nil, err }
+		body, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil { return nil, err }
+	}
+
+	expected := hmac.New(sha256.New, []byte(keyInfo.SigningSecret))
+	expected.Write([]byte(StringToSign(r.Method, r.URL.EscapedPath(), r.URL.Query().Encode(), timestamp, nonce, body)))
+	provided, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(provided, expected.Sum(nil)) { return nil, fmt.Errorf("invalid signature") }
+
+	// Only burn the nonce once the signature is known to be genuine
+	if !nonceCache.Use(keyID, nonce, now) { return nil, fmt.Errorf("nonce already used") }
+	return &keyInfo, nil
+}
+
+func maxClockSkewFromEnv() time.Duration {
+	if v := os.Getenv("SIGNATURE_MAX_SKEW_SECONDS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return defaultMaxClockSkew
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "institution-b-secret"
+
+// signedRequest builds a request signed as a client would sign it.
+func signedRequest(t *testing.T, method, target, body, nonce string, at time.Time) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if err := bufferBody(r); err != nil {
+		t.Fatal(err)
+	}
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(StringToSign(method, r.URL.EscapedPath(), r.URL.Query().Encode(), timestamp, nonce, []byte(body))))
+	r.Header.Set(headerKeyID, "inst-b")
+	r.Header.Set(headerTimestamp, timestamp)
+	r.Header.Set(headerNonce, nonce)
+	r.Header.Set(headerSignature, hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+func withSigningKey(t *testing.T) {
+	t.Helper()
+	withKeys(t, map[string]APIKey{
+		"inst-b": {ClientName: "Institution B", RateLimit: 100, Tier: "paid", SigningKeyID: "inst-b", SigningSecret: testSecret},
+	})
+	saved := nonceCache
+	nonceCache = NewNonceCache(maxClockSkew)
+	t.Cleanup(func() { nonceCache = saved })
+}
+
+func TestStringToSign(t *testing.T) {
+	got := StringToSign("get", "/price", "asset=XAU&unit=g", "1700000000", "0123456789abcdef", nil)
+	want := "GET\n/price\nasset=XAU&unit=g\n1700000000\n0123456789abcdef\n" +
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" // sha256("")
+	if got != want {
+		t.Errorf("StringToSign = %q, want %q", got, want)
+	}
+}
+
+func TestVerifySignedRequest(t *testing.T) {
+	now := time.Now()
+	nonce := "0123456789abcdef"
+	tests := []struct {
+		name    string
+		req     func(t *testing.T) *http.Request
+		wantErr string
+	}{
+		{"valid", func(t *testing.T) *http.Request {
+			return signedRequest(t, "GET", "/price?unit=g&asset=XAU", "", nonce, now)
+		}, ""},
+		{"valid with body", func(t *testing.T) *http.Request {
+			return signedRequest(t, "GET", "/chain", `{"a":1}`, nonce, now)
+		}, ""},
+		{"wrong signature", func(t *testing.T) *http.Request {
+			r := signedRequest(t, "GET", "/price", "", nonce, now)
+			r.Header.Set(headerSignature, strings.Repeat("00", sha256.Size))
+			return r
+		}, "invalid signature"},
+		{"query changed after signing", func(t *testing.T) *http.Request {
+			r := signedRequest(t, "GET", "/price?unit=g", "", nonce, now)
+			r.URL.RawQuery = "unit=kg"
+			return r
+		}, "invalid signature"},
+		{"body changed after signing", func(t *testing.T) *http.Request {
+			r := signedRequest(t, "GET", "/price", `{"a":1}`, nonce, now)
+			r.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(`{"a":2}`)), nil }
+			return r
+		}, "invalid signature"},
+		{"unknown key", func(t *testing.T) *http.Request {
+			r := signedRequest(t, "GET", "/price", "", nonce, now)
+			r.Header.Set(headerKeyID, "someone")
+			return r
+		}, "invalid signing key"},
+		{"missing nonce", func(t *testing.T) *http.Request {
+			r := signedRequest(t, "GET", "/price", "", nonce, now)
+			r.Header.Del(headerNonce)
+			return r
+		}, "incomplete signature headers"},
+		{"short nonce", func(t *testing.T) *http.Request {
+			return signedRequest(t, "GET", "/price", "", "abc", now)
+		}, "invalid nonce"},
+		{"timestamp too old", func(t *testing.T) *http.Request {
+			return signedRequest(t, "GET", "/price", "", nonce, now.Add(-maxClockSkew-time.Minute))
+		}, "clock skew"},
+		{"timestamp too far ahead", func(t *testing.T) *http.Request {
+			return signedRequest(t, "GET", "/price", "", nonce, now.Add(maxClockSkew+time.Minute))
+		}, "clock skew"},
+		{"timestamp at the edge of the window", func(t *testing.T) *http.Request {
+			return signedRequest(t, "GET", "/price", "", nonce, now.Add(-maxClockSkew+time.Minute))
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withSigningKey(t)
+			info, err := verifySignedRequest(tt.req(t))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || info.ClientName != "Institution B" {
+				t.Fatalf("got %+v, %v", info, err)
+			}
+		})
+	}
+}
+
+func TestSignedRequestNonceReplay(t *testing.T) {
+	withSigningKey(t)
+	now := time.Now()
+	first := signedRequest(t, "GET", "/price", "", "replayed-nonce-0001", now)
+	if _, err := verifySignedRequest(first); err != nil {
+		t.Fatal(err)
+	}
+	replay := signedRequest(t, "GET", "/price", "", "replayed-nonce-0001", now)
+	if _, err := verifySignedRequest(replay); err == nil || !strings.Contains(err.Error(), "nonce already used") {
+		t.Fatalf("replay: error = %v", err)
+	}
+
+	// A forged request must not burn the nonce its legitimate owner will use
+	forged := signedRequest(t, "GET", "/price", "", "fresh-nonce-00002", now)
+	forged.Header.Set(headerSignature, strings.Repeat("00", sha256.Size))
+	if _, err := verifySignedRequest(forged); err == nil {
+		t.Fatal("forged signature accepted")
+	}
+	genuine := signedRequest(t, "GET", "/price", "", "fresh-nonce-00002", now)
+	if _, err := verifySignedRequest(genuine); err != nil {
+		t.Fatalf("genuine request after a forgery: %v", err)
+	}
+
+	// Nonces are per key, and forgotten once their timestamp is out of the window
+	if !nonceCache.Use("other-key", "replayed-nonce-0001", now) {
+		t.Error("nonce of one key blocked another")
+	}
+	if !nonceCache.Use("inst-b", "replayed-nonce-0001", now.Add(2*maxClockSkew+time.Second)) {
+		t.Error("nonce still blocked after the skew window")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"aurum-oracle/pkg/calibration"
+	"aurum-oracle/pkg/fixed"
+	"aurum-oracle/pkg/keystore"
+)
+
+// activeCalibration holds the signed per-source offsets applied to quotes;
+// nil means no calibration. It is set once at startup.
+var activeCalibration *calibration.Signed
+
+// calibrationOffset is a source's signed offset in fixed point. Offsets are
+// signed as decimals, so the conversion is exact.
+func calibrationOffset(source string) fixed.Price {
+	off, _ := fixed.FromFloat(activeCalibration.Offset(source))
+	return off
+}
+
+// loadCalibration reads CALIBRATION_FILE, which must be signed by one of
+// CALIBRATION_PUBKEYS (comma-separated hex ed25519 keys).
+func loadCalibration() error {
+	if os.Getenv("PRICE_OFFSET") != "" {
+		return fmt.Errorf("PRICE_OFFSET is no longer honoured; use a signed CALIBRATION_FILE (see `aurum-node sign-calibration`)")
+	}
+	path := os.Getenv("CALIBRATION_FILE")
+	if path == "" {
+		return nil
+	}
+	var trusted []string
+	for _, k := range strings.Split(os.Getenv("CALIBRATION_PUBKEYS"), ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			trusted = append(trusted, k)
+		}
+	}
+	if len(trusted) == 0 {
+		return fmt.Errorf("CALIBRATION_FILE requires CALIBRATION_PUBKEYS")
+	}
+	signed, err := calibration.Load(path, trusted)
+	if err != nil {
+		return err
+	}
+	activeCalibration = signed
+	c := signed.Calibration
+	log.Printf("🎚️  Calibration v%d set by %s at %s: %v", c.Version, c.SetBy, time.Unix(c.SetAt, 0).UTC().Format(time.RFC3339), c.Offsets)
+	return nil
+}
+
+// runCommand dispatches `aurum-node <command> [flags]` and returns the exit code.
+func runCommand(name string, args []string) int {
+	switch name {
+	case "sign-calibration":
+		return cmdSignCalibration(args)
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\ncommands:\n  sign-calibration   sign a calibration file with a keystore key\n", name)
+	return 2
+}
+
+// cmdSignCalibration reads an unsigned calibration ({"version", "offsets",
+// "set_by", "reason"}), stamps set_at and prints the signed file.
+func cmdSignCalibration(args []string) int {
+	fs := flag.NewFlagSet("sign-calibration", flag.ContinueOnError)
+	in := fs.String("in", "", "unsigned calibration JSON")
+	keyPath := fs.String("key", "", "keystore of the calibration authority")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *in == "" || *keyPath == "" {
+		fmt.Fprintln(os.Stderr, "-in and -key are required")
+		return 2
+	}
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	var c calibration.Calibration
+	if err := json.Unmarshal(data, &c); err != nil {
+		fmt.Fprintf(os.Stderr, "parse %s: %v\n", *in, err)
+		return 1
+	}
+	if c.SetBy == "" {
+		fmt.Fprintln(os.Stderr, "set_by is required")
+		return 2
+	}
+	if c.SetAt == 0 {
+		c.SetAt = time.Now().Unix()
+	}
+	key, _, err := keystore.ReadFile(*keyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "signing key: %v\n", err)
+		return 1
+	}
+
+	signed := calibration.Sign(c, key)
+	if err := signed.VerifySignature(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(signed)
+	return 0
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+// FX rates let the aggregator publish gold in currencies other than USD.
+// Every FX source reports units of the currency per 1 USD; the node serves
+// the median across its fresh sources, as it does for gold.
+
+// fxCurrencies reads FX_CURRENCIES, e.g. "EUR,GBP,CHF,JPY" (the default).
+var fxCurrencies = func() []string {
+	list := os.Getenv("FX_CURRENCIES")
+	if list == "" {
+		list = "EUR,GBP,CHF,JPY"
+	}
+	var out []string
+	for _, c := range strings.Split(list, ",") {
+		if c = strings.ToUpper(strings.TrimSpace(c)); len(c) == 3 && c != "USD" {
+			out = append(out, c)
+		}
+	}
+	return out
+}()
+
+type FXSource struct {
+	Name     string
+	Interval time.Duration
+	TTL      time.Duration
+	MaxAge   time.Duration
+	Fetch    func(keys map[string]string, currencies []string) (FXQuote, error)
+}
+
+// FXQuote is one source's rates, currency -> units per USD.
+type FXQuote struct {
+	Rates map[string]fixed.Price `json:"rates_e8"`
+	Time  int64                  `json:"time,omitempty"` // source timestamp, unix seconds
+}
+
+// swissquoteInverted lists currencies quoted as CCY/USD rather than USD/CCY.
+var swissquoteInverted = map[string]bool{"EUR": true, "GBP": true, "AUD": true, "NZD": true}
+
+var fxSources = []FXSource{
+	// ECB reference rates, published once per working day
+	{
+		Name:     "FX_Frankfurter",
+		Interval: time.Hour,
+		MaxAge:   96 * time.Hour, // Friday's fixing has to last the weekend
+		Fetch: func(keys map[string]string, currencies []string) (FXQuote, error) {
+			client := &http.Client{Timeout: 10 * time.Second}
+			resp, err := client.Get("https://api.frankfurter.app/latest?from=USD&to=" + strings.Join(currencies, ","))
+			if err != nil {
+				return FXQuote{}, err
+			}
+			defer resp.Body.Close()
+			if err := checkStatus(resp); err != nil {
+				return FXQuote{}, err
+			}
+			var data struct {
+				Date  string                 `json:"date"`
+				Rates map[string]json.Number `json:"rates"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+				return FXQuote{}, err
+			}
+			q := FXQuote{}
+			if day, err := time.Parse("2006-01-02", data.Date); err == nil {
+				q.Time = day.Unix()
+			}
+			q.Rates, err = parseRates(data.Rates, currencies)
+			return q, err
+		},
+	},
+	{
+		Name:     "FX_OpenER",
+		Interval: time.Hour,
+		MaxAge:   48 * time.Hour,
+		Fetch: func(keys map[string]string, currencies []string) (FXQuote, error) {
+			client := &http.Client{Timeout: 10 * time.Second}
+			resp, err := client.Get("https://open.er-api.com/v6/latest/USD")
+			if err != nil {
+				return FXQuote{}, err
+			}
+			defer resp.Body.Close()
+			if err := checkStatus(resp); err != nil {
+				return FXQuote{}, err
+			}
+			var data struct {
+				Result  string                 `json:"result"`
+				Updated int64                  `json:"time_last_update_unix"`
+				Rates   map[string]json.Number `json:"rates"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+				return FXQuote{}, err
+			}
+			if data.Result != "success" {
+				return FXQuote{}, fmt.Errorf("result %q", data.Result)
+			}
+			q := FXQuote{Time: data.Updated}
+			q.Rates, err = parseRates(data.Rates, currencies)
+			return q, err
+		},
+	},
+	// Live interbank mid, one request per pair
+	{
+		Name:     "FX_Swissquote",
+		Interval: 30 * time.Second,
+		MaxAge:   2 * time.Minute,
+		Fetch: func(keys map[string]string, currencies []string) (FXQuote, error) {
+			q := FXQuote{Rates: map[string]fixed.Price{}}
+			var lastErr error
+			for _, c := range currencies {
+				pair := "USD/" + c
+				if swissquoteInverted[c] {
+					pair = c + "/USD"
+				}
+				bbo, err := swissquoteQuote(pair)
+				if err != nil {
+					lastErr = fmt.Errorf("%s: %w", pair, err)
+					continue
+				}
+				if bbo.Mid <= 0 {
+					continue
+				}
+				rate := bbo.Mid
+				if swissquoteInverted[c] {
+					if rate, err = fixed.Div(fixed.One, rate); err != nil {
+						lastErr = fmt.Errorf("%s: %w", pair, err)
+						continue
+					}
+				}
+				q.Rates[c] = rate
+				// The quote is as old as its oldest pair
+				if q.Time == 0 || (bbo.Time > 0 && bbo.Time < q.Time) {
+					q.Time = bbo.Time
+				}
+			}
+			if len(q.Rates) == 0 && lastErr != nil {
+				return FXQuote{}, lastErr
+			}
+			return q, nil
+		},
+	},
+}
+
+// parseRates keeps the requested currencies from a JSON rate table.
+func parseRates(table map[string]json.Number, currencies []string) (map[string]fixed.Price, error) {
+	rates := map[string]fixed.Price{}
+	for _, c := range currencies {
+		n, ok := table[c]
+		if !ok {
+			continue
+		}
+		r, err := fixed.Parse(n.String())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c, err)
+		}
+		rates[c] = r
+	}
+	return rates, nil
+}
+
+// fxStatus is the state of one FX source; like SourceStatus it doubles as the cache.
+type fxStatus struct {
+	Name       string                 `json:"source"`
+	OK         bool                   `json:"ok"`
+	Rates      map[string]fixed.Price `json:"rates_e8,omitempty"`
+	Time       int64                  `json:"time,omitempty"`
+	AgeSeconds int64                  `json:"age_seconds"` // from the upstream time, else the fetch
+	Fresh      bool                   `json:"used"`
+	LastError  string                 `json:"last_error,omitempty"`
+	Limits     LimiterStatus          `json:"limits"`
+
+	fetchedAt time.Time
+	ttl       time.Duration
+	maxAge    time.Duration
+	limiter   *sourceLimiter
+}
+
+var fxState = struct {
+	sync.RWMutex
+	sources map[string]*fxStatus
+}{sources: map[string]*fxStatus{}}
+
+// startFXPollers polls FX sources with the same schedule, budget and
+// breaker settings as price sources (SOURCE_FX_<NAME>_...).
+func startFXPollers() {
+	if len(fxCurrencies) == 0 {
+		return
+	}
+	for _, s := range fxSources {
+		base := PriceSource{Name: s.Name, Type: "fx", Interval: s.Interval, TTL: s.TTL, MaxAge: s.MaxAge}
+		interval, ttl, maxAge := sourceSchedule(base)
+		limiter := newSourceLimiter(base, interval)
+		fxState.Lock()
+		fxState.sources[s.Name] = &fxStatus{Name: s.Name, ttl: ttl, maxAge: maxAge, limiter: limiter}
+		fxState.Unlock()
+		log.Printf("⏱️  [%s] polling every %s, cached for %s", s.Name, interval, ttl)
+		go pollFX(s, maxAge, limiter)
+	}
+}
+
+func pollFX(s FXSource, maxAge time.Duration, limiter *sourceLimiter) {
+	for {
+		if ok, wait := limiter.allow(time.Now()); !ok {
+			if wait > maxPollSleep {
+				wait = maxPollSleep
+			}
+			time.Sleep(wait)
+			continue
+		}
+		q, err := s.Fetch(apiKeys(), fxCurrencies)
+		if err == nil {
+			for c, r := range q.Rates {
+				if r <= 0 {
+					err = fmt.Errorf("invalid %s rate %v", c, r)
+				}
+			}
+		}
+		if err == nil && len(q.Rates) == 0 {
+			err = fmt.Errorf("no rates for %s", strings.Join(fxCurrencies, ","))
+		}
+		if err == nil {
+			err = checkQuoteTime(Quote{Time: q.Time}, maxAge, time.Now())
+		}
+		limiter.record(time.Now(), err)
+
+		fxState.Lock()
+		st := fxState.sources[s.Name]
+		changed := st.OK != (err == nil) || (st.fetchedAt.IsZero() && st.LastError == "")
+		st.OK = err == nil
+		if err != nil {
+			st.LastError = err.Error()
+		} else {
+			st.Rates, st.Time, st.fetchedAt = q.Rates, q.Time, time.Now()
+		}
+		fxState.Unlock()
+		if changed {
+			if err != nil {
+				log.Printf("⚠️  [%s] Failed: %v", s.Name, err)
+			} else {
+				log.Printf("✅ [%s] %d rates", s.Name, len(q.Rates))
+			}
+		}
+	}
+}
+
+func fxSnapshot() []fxStatus {
+	fxState.RLock()
+	defer fxState.RUnlock()
+	now := time.Now()
+	list := make([]fxStatus, 0, len(fxState.sources))
+	for _, st := range fxState.sources {
+		cp := *st
+		cp.Limits = st.limiter.status()
+		if !st.fetchedAt.IsZero() {
+			age := now.Sub(st.fetchedAt)
+			quoteAge := age
+			if st.Time > 0 {
+				quoteAge = now.Sub(time.Unix(st.Time, 0))
+			}
+			if quoteAge < 0 {
+				quoteAge = 0
+			}
+			cp.AgeSeconds = int64(quoteAge.Seconds())
+			cp.Fresh = age <= st.ttl && (st.maxAge <= 0 || quoteAge <= st.maxAge)
+		}
+		list = append(list, cp)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// currentFX is the "fx" part of the /price response: the median rate per
+// currency over fresh sources, and the inputs it came from.
+func currentFX() map[string]interface{} {
+	snapshot := fxSnapshot()
+	rates := map[string]fixed.Price{}
+	display := map[string]float64{}
+	counts := map[string]int{}
+	var oldest int64
+	for _, c := range fxCurrencies {
+		var values []fixed.Price
+		for _, st := range snapshot {
+			if r, ok := st.Rates[c]; ok && st.Fresh {
+				values = append(values, r)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		rates[c], counts[c] = fixed.Median(values), len(values)
+		display[c] = rates[c].Float()
+	}
+	for _, st := range snapshot {
+		if st.Fresh && st.AgeSeconds > oldest {
+			oldest = st.AgeSeconds
+		}
+	}
+	return map[string]interface{}{
+		"base":               "USD",
+		"rates":              display,
+		"rates_e8":           rates,
+		"sources":            counts,
+		"oldest_age_seconds": oldest,
+		"inputs":             snapshot,
+	}
+}
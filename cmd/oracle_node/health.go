@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+const defaultMinSources = 1
+
+var startedAt = time.Now()
+
+// SourceStatus is the last thing we know about one upstream PriceSource.
+// It doubles as the quote cache: LastPrice is served until it is TTL old.
+type SourceStatus struct {
+	Name            string      `json:"name"`
+	Type            string      `json:"type"`
+	OK              bool        `json:"ok"` // last attempt succeeded
+	LastSuccess     int64       `json:"last_success,omitempty"`
+	LastPrice       fixed.Price `json:"last_price_e8,omitempty"` // Quote.Price(PRICE_MODE)
+	Quote           Quote       `json:"quote"`
+	LastError       string      `json:"last_error,omitempty"`
+	LastErrorAt     int64       `json:"last_error_at,omitempty"`
+	LatencyMs       int64       `json:"latency_ms"`
+	IntervalSeconds int64       `json:"interval_seconds"`
+	TTLSeconds      int64       `json:"ttl_seconds"`
+	MaxAgeSeconds   int64       `json:"max_age_seconds,omitempty"`
+	// Computed when read. QuoteAgeSeconds counts from the upstream quote
+	// time, or from the fetch when the source reports none.
+	AgeSeconds      int64         `json:"age_seconds"`
+	QuoteAgeSeconds int64         `json:"quote_age_seconds"`
+	Fresh           bool          `json:"fresh"`
+	Limits          LimiterStatus `json:"limits"`
+
+	lastSuccess time.Time
+	ttl         time.Duration
+	maxAge      time.Duration
+	limiter     *sourceLimiter
+}
+
+var sourceHealth = struct {
+	sync.RWMutex
+	sources map[string]*SourceStatus
+}{sources: map[string]*SourceStatus{}}
+
+// registerSource makes a source visible before its first poll completes.
+func registerSource(s PriceSource, interval, ttl, maxAge time.Duration, limiter *sourceLimiter) {
+	sourceHealth.Lock()
+	defer sourceHealth.Unlock()
+	sourceHealth.sources[s.Name] = &SourceStatus{
+		Name:            s.Name,
+		Type:            s.Type,
+		IntervalSeconds: int64(interval.Seconds()),
+		TTLSeconds:      int64(ttl.Seconds()),
+		MaxAgeSeconds:   int64(maxAge.Seconds()),
+		ttl:             ttl,
+		maxAge:          maxAge,
+		limiter:         limiter,
+	}
+}
+
+// recordSource stores the outcome of one poll and reports whether the
+// source's ok/failing state changed.
+func recordSource(name string, q Quote, err error, dur time.Duration) bool {
+	sourceHealth.Lock()
+	defer sourceHealth.Unlock()
+	st := sourceHealth.sources[name]
+	now := time.Now()
+	changed := st.OK != (err == nil) || (st.LastSuccess == 0 && st.LastErrorAt == 0)
+	st.LatencyMs = dur.Milliseconds()
+	st.OK = err == nil
+	if err != nil {
+		st.LastError = err.Error()
+		st.LastErrorAt = now.Unix()
+		return changed
+	}
+	st.lastSuccess = now
+	st.LastSuccess = now.Unix()
+	st.LastPrice = q.Price(priceMode)
+	st.Quote = q
+	return changed
+}
+
+// sourceSnapshot copies every source's status with age and freshness filled in.
+func sourceSnapshot() []SourceStatus {
+	sourceHealth.RLock()
+	defer sourceHealth.RUnlock()
+	now := time.Now()
+	list := make([]SourceStatus, 0, len(sourceHealth.sources))
+	for _, st := range sourceHealth.sources {
+		cp := *st
+		cp.Limits = st.limiter.status()
+		if !st.lastSuccess.IsZero() {
+			age := now.Sub(st.lastSuccess)
+			quoteAge := age
+			if st.Quote.Time > 0 {
+				quoteAge = now.Sub(time.Unix(st.Quote.Time, 0))
+			}
+			if quoteAge < 0 {
+				quoteAge = 0
+			}
+			cp.AgeSeconds = int64(age.Seconds())
+			cp.QuoteAgeSeconds = int64(quoteAge.Seconds())
+			// A cached quote also ages out against the upstream clock
+			cp.Fresh = age <= st.ttl && (st.maxAge <= 0 || quoteAge <= st.maxAge)
+		}
+		list = append(list, cp)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// minSources reads MIN_SOURCES: how many sources must agree before the
+// node will serve a price.
+func minSources() int {
+	if n, err := strconv.Atoi(os.Getenv("MIN_SOURCES")); err == nil && n > 0 {
+		return n
+	}
+	return defaultMinSources
+}
+
+// readiness reports whether enough sources hold a fresh quote to produce a price.
+func readiness() (bool, int, []SourceStatus) {
+	list := sourceSnapshot()
+	healthy := 0
+	for _, st := range list {
+		if st.Fresh {
+			healthy++
+		}
+	}
+	return healthy >= minSources(), healthy, list
+}
+
+// healthHandler is liveness only: the process is up and serving HTTP.
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":         "ok",
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+	})
+}
+
+// readyHandler answers 200 when the node can currently produce a price from
+// at least MIN_SOURCES sources, 503 otherwise, with per-source detail.
+func readyHandler(w http.ResponseWriter, r *http.Request) {
+	ready, healthy, list := readiness()
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ready":           ready,
+		"healthy_sources": healthy,
+		"min_sources":     minSources(),
+		"sources":         list,
+		"timestamp":       time.Now().Unix(),
+	})
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+// withSources gives a test its own source registry.
+func withSources(t *testing.T) {
+	t.Helper()
+	sourceHealth.Lock()
+	saved := sourceHealth.sources
+	sourceHealth.sources = map[string]*SourceStatus{}
+	sourceHealth.Unlock()
+	t.Cleanup(func() {
+		sourceHealth.Lock()
+		sourceHealth.sources = saved
+		sourceHealth.Unlock()
+	})
+}
+
+// addSource registers a source the way startPollers does.
+func addSource(t *testing.T, name, typ string, ttl, maxAge time.Duration) {
+	t.Helper()
+	s := PriceSource{Name: name, Type: typ, Interval: time.Second}
+	registerSource(s, time.Second, ttl, maxAge, newSourceLimiter(s, time.Second))
+}
+
+func TestReadiness(t *testing.T) {
+	withSources(t)
+	for _, name := range []string{"A", "B", "C"} {
+		addSource(t, name, "fiat", time.Minute, time.Minute)
+	}
+	recordSource("A", Quote{Last: 2650 * fixed.One}, nil, time.Millisecond)
+	recordSource("B", Quote{Last: 2651 * fixed.One}, nil, time.Millisecond)
+	recordSource("C", Quote{}, errors.New("status 500"), time.Millisecond)
+
+	tests := []struct {
+		minSources string
+		wantReady  bool
+		wantStatus int
+	}{
+		{"", true, http.StatusOK}, // defaults to 1
+		{"2", true, http.StatusOK},
+		{"3", false, http.StatusServiceUnavailable},
+		{"nonsense", true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Setenv("MIN_SOURCES", tt.minSources)
+		ready, healthy, list := readiness()
+		if ready != tt.wantReady || healthy != 2 || len(list) != 3 {
+			t.Errorf("MIN_SOURCES=%q: ready %v, %d healthy of %d", tt.minSources, ready, healthy, len(list))
+		}
+		w := httptest.NewRecorder()
+		readyHandler(w, httptest.NewRequest("GET", "/ready", nil))
+		if w.Code != tt.wantStatus {
+			t.Errorf("MIN_SOURCES=%q: /ready status %d, want %d", tt.minSources, w.Code, tt.wantStatus)
+		}
+	}
+
+	// A source that fails after a success keeps serving its cached quote
+	// until the TTL, but is reported as failing
+	recordSource("A", Quote{}, errors.New("timeout"), time.Millisecond)
+	for _, st := range sourceSnapshot() {
+		if st.Name == "A" && (st.OK || !st.Fresh || st.LastError != "timeout") {
+			t.Errorf("failing source with a cached quote: %+v", st)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	healthHandler(w, httptest.NewRequest("GET", "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("/health status %d", w.Code)
+	}
+}
+
+func TestSourceSnapshotMaxAge(t *testing.T) {
+	withSources(t)
+	addSource(t, "Timed", "fiat", time.Hour, 2*time.Minute)
+	addSource(t, "Untimed", "crypto", time.Hour, 2*time.Minute)
+	now := time.Now()
+
+	// Just fetched, but the upstream quote is older than the max age
+	recordSource("Timed", Quote{Last: 2650 * fixed.One, Time: now.Add(-3 * time.Minute).Unix()}, nil, time.Millisecond)
+	recordSource("Untimed", Quote{Last: 2650 * fixed.One}, nil, time.Millisecond)
+	fresh := map[string]bool{}
+	for _, st := range sourceSnapshot() {
+		fresh[st.Name] = st.Fresh
+	}
+	if fresh["Timed"] || !fresh["Untimed"] {
+		t.Fatalf("freshness %v, want only Untimed", fresh)
+	}
+
+	// Without an upstream time the max age counts from the fetch, well
+	// inside the TTL
+	sourceHealth.Lock()
+	sourceHealth.sources["Untimed"].lastSuccess = now.Add(-3 * time.Minute)
+	sourceHealth.Unlock()
+	for _, st := range sourceSnapshot() {
+		if st.Name == "Untimed" && (st.Fresh || st.QuoteAgeSeconds < 180) {
+			t.Errorf("untimed quote past its max age: %+v", st)
+		}
+	}
+	if age := oldestQuoteAge(sourceSnapshot()); age != 0 {
+		t.Errorf("oldest quote age %d over no fresh quotes", age)
+	}
+}
@@ -1,5 +1,6 @@
 // oracle_node.go - The "Ultimate" Multi-Source Oracle
 // Sources: GoldAPI.io , GoldAPI.com, Swissquote, Binance, Kraken, Investing
+// FX (fx.go): Frankfurter/ECB, open.er-api, Swissquote
 package main
 
 import (
@@ -10,18 +11,22 @@ import (
 	"net/http"
 	"os"
 	"regexp"
-	"sort"
-	"strconv"
 	"strings"
 	"time"
+
+	"aurum-oracle/pkg/fixed"
+	"aurum-oracle/pkg/listener"
 )
 
 const DEFAULT_PORT = "8080"
 
 type PriceSource struct {
-	Name  string
-	Type  string // "fiat" or "crypto"
-	Fetch func(keys map[string]string) (float64, error)
+	Name     string
+	Type     string        // "fiat" or "crypto"
+	Interval time.Duration // background poll period
+	TTL      time.Duration // how long a quote stays usable (0 = 3 intervals)
+	MaxAge   time.Duration // oldest quote accepted, by upstream time or else fetch time (0 = the TTL)
+	Fetch    func(keys map[string]string) (Quote, error)
 }
 
 var sources = []PriceSource{
@@ -29,222 +34,282 @@ var sources = []PriceSource{
 	{
 		Name: "GoldAPI_IO",
 		Type: "fiat",
-		Fetch: func(keys map[string]string) (float64, error) {
+		Interval: 60 * time.Second,
+		MaxAge: 10 * time.Minute,
+		Fetch: func(keys map[string]string) (Quote, error) {
 			apiKey := keys["GOLDAPI_IO_KEY"]
-			if apiKey == "" { return 0, fmt.Errorf("missing API key") }
+			if apiKey == "" { return Quote{}, fmt.Errorf("missing API key") }
 			
 			client := &http.Client{Timeout: 10 * time.Second}
 			req, _ := http.NewRequest("GET", "https://www.goldapi.io/api/XAU/USD", nil)
 			req.Header.Set("x-access-token", apiKey)
 			
 			resp, err := client.Do(req)
-			if err != nil { return 0, err }
+			if err != nil { return Quote{}, err }
 			defer resp.Body.Close()
 			
-			if resp.StatusCode != 200 { return 0, fmt.Errorf("status %d", resp.StatusCode) }
+			if err := checkStatus(resp); err != nil { return Quote{}, err }
 			
-			var data struct { Price float64 `json:"price"` }
-			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil { return 0, err }
-			return data.Price, nil
+			var data struct {
+				Price     json.Number `json:"price"`
+				Bid       json.Number `json:"bid"`
+				Ask       json.Number `json:"ask"`
+				Timestamp int64       `json:"timestamp"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil { return Quote{}, err }
+			q, err := parseQuote(data.Bid.String(), data.Ask.String(), data.Price.String())
+			q.Time = unixTime(data.Timestamp)
+			return q, err
 		},
 	},
 	// 2. Gold-API.com (Backup)
 	{
 		Name: "GoldAPI_COM",
 		Type: "fiat",
-		Fetch: func(keys map[string]string) (float64, error) {
+		Interval: 60 * time.Second,
+		MaxAge: 10 * time.Minute,
+		Fetch: func(keys map[string]string) (Quote, error) {
 			apiKey := keys["GOLDAPI_COM_KEY"]
-			if apiKey == "" { return 0, fmt.Errorf("missing API key") }
+			if apiKey == "" { return Quote{}, fmt.Errorf("missing API key") }
 			
 			client := &http.Client{Timeout: 10 * time.Second}
 			req, _ := http.NewRequest("GET", "https://gold-api.com/api/XAU/USD", nil)
 			req.Header.Set("Authorization", "Bearer " + apiKey)
 			
 			resp, err := client.Do(req)
-			if err != nil { return 0, err }
+			if err != nil { return Quote{}, err }
 			defer resp.Body.Close()
 			
-			if resp.StatusCode != 200 { return 0, fmt.Errorf("status %d", resp.StatusCode) }
+			if err := checkStatus(resp); err != nil { return Quote{}, err }
 			
-			var data struct { Price float64 `json:"price"` }
-			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil { return 0, err }
-			return data.Price, nil
+			var data struct {
+				Price     json.Number `json:"price"`
+				UpdatedAt time.Time   `json:"updatedAt"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil { return Quote{}, err }
+			q, err := parseQuote("", "", data.Price.String())
+			if !data.UpdatedAt.IsZero() { q.Time = data.UpdatedAt.Unix() }
+			return q, err
 		},
 	},
 	// 3. Swissquote (Forex)
 	{
 		Name: "Swissquote",
 		Type: "fiat",
-		Fetch: func(keys map[string]string) (float64, error) {
-			client := &http.Client{Timeout: 5 * time.Second}
-			resp, err := client.Get("https://forex-data-feed.swissquote.com/public-quotes/bboquotes/instrument/XAU/USD")
-			if err != nil { return 0, err }
-			defer resp.Body.Close()
-			var data []struct {
-				Topo struct { Ask float64 `json:"ask"` } `json:"topo"`
-			}
-			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil { return 0, err }
-			if len(data) == 0 { return 0, fmt.Errorf("empty response") }
-			return data[0].Topo.Ask, nil
+		Interval: 10 * time.Second,
+		MaxAge: 2 * time.Minute,
+		Fetch: func(keys map[string]string) (Quote, error) {
+			return swissquoteQuote("XAU/USD")
 		},
 	},
 	// 4. Binance PAXG (Crypto)
 	{
 		Name: "Binance",
 		Type: "crypto",
-		Fetch: func(keys map[string]string) (float64, error) {
+		Interval: 10 * time.Second,
+		MaxAge: 2 * time.Minute,
+		Fetch: func(keys map[string]string) (Quote, error) {
 			client := &http.Client{Timeout: 5 * time.Second}
-			resp, err := client.Get("https://api.binance.us/api/v3/ticker/price?symbol=PAXGUSDT")
+			resp, err := client.Get("https://api.binance.us/api/v3/ticker/24hr?symbol=PAXGUSDT")
 			if err != nil {
-				resp, err = client.Get("https://api.binance.com/api/v3/ticker/price?symbol=PAXGUSDT")
+				resp, err = client.Get("https://api.binance.com/api/v3/ticker/24hr?symbol=PAXGUSDT")
 			}
-			if err != nil { return 0, err }
+			if err != nil { return Quote{}, err }
 			defer resp.Body.Close()
-			var data struct { Price string `json:"price"` }
-			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil { return 0, err }
-			return strconv.ParseFloat(data.Price, 64)
+			if err := checkStatus(resp); err != nil { return Quote{}, err }
+			var data struct {
+				Bid       string `json:"bidPrice"`
+				Ask       string `json:"askPrice"`
+				Last      string `json:"lastPrice"`
+				CloseTime int64  `json:"closeTime"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil { return Quote{}, err }
+			q, err := parseQuote(data.Bid, data.Ask, data.Last)
+			q.Time = unixTime(data.CloseTime)
+			return q, err
 		},
 	},
 	// 5. Kraken PAXG (Crypto)
 	{
 		Name: "Kraken",
 		Type: "crypto",
-		Fetch: func(keys map[string]string) (float64, error) {
+		Interval: 10 * time.Second,
+		MaxAge: 2 * time.Minute, // no upstream time: counts from the fetch
+		Fetch: func(keys map[string]string) (Quote, error) {
 			client := &http.Client{Timeout: 5 * time.Second}
 			resp, err := client.Get("https://api.kraken.com/0/public/Ticker?pair=PAXGUSD")
-			if err != nil { return 0, err }
+			if err != nil { return Quote{}, err }
 			defer resp.Body.Close()
-			var data struct { Result map[string]struct { C []string `json:"c"` } `json:"result"` }
-			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil { return 0, err }
-			for _, pair := range data.Result { return strconv.ParseFloat(pair.C[0], 64) }
-			return 0, fmt.Errorf("parse error")
+			if err := checkStatus(resp); err != nil { return Quote{}, err }
+			// a = ask, b = bid, c = last trade; each is [price, ...]
+			var data struct {
+				Result map[string]struct {
+					A []string `json:"a"`
+					B []string `json:"b"`
+					C []string `json:"c"`
+				} `json:"result"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil { return Quote{}, err }
+			first := func(v []string) string { if len(v) == 0 { return "" }; return v[0] }
+			for _, pair := range data.Result { return parseQuote(first(pair.B), first(pair.A), first(pair.C)) }
+			return Quote{}, fmt.Errorf("parse error")
 		},
 	},
 	// 6. Investing.com (Scraper)
 	{
 		Name: "Investing.com",
 		Type: "fiat",
-		Fetch: func(keys map[string]string) (float64, error) {
+		Interval: 60 * time.Second,
+		MaxAge: 10 * time.Minute, // no upstream time: counts from the fetch
+		Fetch: func(keys map[string]string) (Quote, error) {
 			client := &http.Client{Timeout: 8 * time.Second}
 			req, _ := http.NewRequest("GET", "https://www.investing.com/currencies/xau-usd", nil)
 			req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; AurumBot/1.0)")
 			resp, err := client.Do(req)
-			if err != nil { return 0, err }
+			if err != nil { return Quote{}, err }
 			defer resp.Body.Close()
+			if err := checkStatus(resp); err != nil { return Quote{}, err }
 			body, _ := io.ReadAll(resp.Body)
-			html := string(body)
-			re := regexp.MustCompile(`([0-9]{1},?[0-9]{3}\.[0-9]{2})`)
-			matches := re.FindAllString(html, -1)
-			for _, match := range matches {
-				cleaned := strings.ReplaceAll(match, ",", "")
-				price, err := strconv.ParseFloat(cleaned, 64)
-				if err == nil && price > 1500 && price < 5000 { return price, nil }
-			}
-			return 0, fmt.Errorf("price pattern not found")
+			lo, hi, err := plausibleRange("Investing.com", 1500*fixed.One, 5000*fixed.One)
+			if err != nil { return Quote{}, err }
+			price, err := scrapePrice(string(body), lo, hi)
+			return Quote{Last: price}, err
 		},
 	},
 }
 
-func fetchAllPrices(keys map[string]string) (float64, int, map[string]interface{}, float64, error) {
-	type result struct {
-		name  string
-		stype string
-		price float64
-		err   error
-		dur   time.Duration
+var scrapedPricePattern = regexp.MustCompile(`[0-9]{1,3}(?:,?[0-9]{3})+\.[0-9]{2}`)
+
+// scrapePrice returns the first price on a page inside (lo, hi). The page
+// carries many numbers, so the range is what tells the quote apart.
+func scrapePrice(html string, lo, hi fixed.Price) (fixed.Price, error) {
+	for _, match := range scrapedPricePattern.FindAllString(html, -1) {
+		price, err := fixed.Parse(strings.ReplaceAll(match, ",", ""))
+		if err == nil && price > lo && price < hi { return price, nil }
 	}
-	results := make(chan result, len(sources))
-	
-	for _, source := range sources {
-		go func(s PriceSource) {
-			start := time.Now()
-			price, err := s.Fetch(keys)
-			results <- result{name: s.Name, stype: s.Type, price: price, err: err, dur: time.Since(start)}
-		}(source)
+	return 0, fmt.Errorf("no price between %s and %s found", lo, hi)
+}
+
+// plausibleRange lets SOURCE_<NAME>_MIN_PRICE / _MAX_PRICE override the
+// price range a scraped source accepts, as gold moves out of the default.
+func plausibleRange(name string, lo, hi fixed.Price) (fixed.Price, fixed.Price, error) {
+	prefix := sourceEnvPrefix(name)
+	for _, v := range []struct {
+		key string
+		dst *fixed.Price
+	}{{prefix + "MIN_PRICE", &lo}, {prefix + "MAX_PRICE", &hi}} {
+		raw := os.Getenv(v.key)
+		if raw == "" { continue }
+		p, err := fixed.Parse(raw)
+		if err != nil { return 0, 0, fmt.Errorf("%s: %v", v.key, err) }
+		*v.dst = p
 	}
-	
-	var prices []float64
-	var cryptoPrices []float64
-	var fiatPrices []float64
+	if lo >= hi { return 0, 0, fmt.Errorf("%sMIN_PRICE %s is not below MAX_PRICE %s", prefix, lo, hi) }
+	return lo, hi, nil
+}
+
+// currentPrices aggregates the quotes the background pollers have cached;
+// sources whose quote is older than their TTL are left out.
+func currentPrices() (fixed.Price, int, map[string]interface{}, fixed.Price, []SourceStatus, error) {
+	snapshot := sourceSnapshot()
+	var prices []fixed.Price
+	var cryptoPrices []fixed.Price
+	var fiatPrices []fixed.Price
 	successCount := 0
 	
-	for i := 0; i < len(sources); i++ {
-		res := <-results
-		if res.err != nil {
-			log.Printf("⚠️  [%s] Failed: %v", res.name, res.err)
-			continue
-		}
-		if res.price < 1000 { continue }
-		
-		log.Printf("✅ [%s] $%.2f (%dms)", res.name, res.price, res.dur.Milliseconds())
-		prices = append(prices, res.price)
+	for _, st := range snapshot {
+		if !st.Fresh { continue }
+		// Signed per-source bias correction (see calibration.go)
+		price := st.LastPrice + calibrationOffset(st.Name)
+		prices = append(prices, price)
 		
-		if res.stype == "crypto" { cryptoPrices = append(cryptoPrices, res.price) }
-		if res.stype == "fiat" { fiatPrices = append(fiatPrices, res.price) }
+		if st.Type == "crypto" { cryptoPrices = append(cryptoPrices, price) }
+		if st.Type == "fiat" { fiatPrices = append(fiatPrices, price) }
 		successCount++
 	}
 	
-	if len(prices) == 0 { return 0, 0, nil, 0, fmt.Errorf("all sources failed") }
+	if len(prices) == 0 { return 0, 0, nil, 0, snapshot, fmt.Errorf("no fresh source quotes") }
+	if len(prices) < minSources() {
+		return 0, 0, nil, 0, snapshot, fmt.Errorf("only %d of %d required sources fresh", len(prices), minSources())
+	}
 	
-	sort.Float64s(prices)
-	median := prices[len(prices)/2]
-	if len(prices)%2 == 0 { median = (prices[len(prices)/2-1] + prices[len(prices)/2]) / 2 }
+	median := fixed.Median(prices)
 	
-	stats := make(map[string]interface{})
+	// Fiat and crypto medians (0 when a side has no sources)
+	fiatMedian, cryptoMedian := fixed.Median(fiatPrices), fixed.Median(cryptoPrices)
+	stats := map[string]interface{}{
+		"fiat_median":    fiatMedian,
+		"fiat_sources":   len(fiatPrices),
+		"crypto_median":  cryptoMedian,
+		"crypto_sources": len(cryptoPrices),
+	}
 	
-	// Fiat Stats
-	if len(fiatPrices) > 0 {
-		sort.Float64s(fiatPrices)
-		fiatMedian := fiatPrices[len(fiatPrices)/2]
-		stats["fiat_median"] = fiatMedian
-		stats["fiat_sources"] = len(fiatPrices)
-	} else {
-		stats["fiat_median"] = 0.0
-		stats["fiat_sources"] = 0
+	// Spread Calculation
+	var spread fixed.Price
+	if fiatMedian > 0 && cryptoMedian > 0 {
+		spread = cryptoMedian - fiatMedian
 	}
+	return median, successCount, stats, spread, snapshot, nil
+}
 
-	// Crypto Stats
-	if len(cryptoPrices) > 0 {
-		sort.Float64s(cryptoPrices)
-		cryptoMedian := cryptoPrices[len(cryptoPrices)/2]
-		stats["crypto_median"] = cryptoMedian
-		stats["crypto_sources"] = len(cryptoPrices)
-	} else {
-		stats["crypto_median"] = 0.0
-		stats["crypto_sources"] = 0
+// spreadBps is the PAXG premium over spot in basis points, in fixed point;
+// false when either side has no fresh sources.
+func spreadBps(stats map[string]interface{}) (fixed.Price, bool, error) {
+	fiat, _ := stats["fiat_median"].(fixed.Price)
+	crypto, _ := stats["crypto_median"].(fixed.Price)
+	if fiat <= 0 || crypto <= 0 {
+		return 0, false, nil
 	}
-	
-	// Spread Calculation
-	spread := 0.0
-	fiatVal := stats["fiat_median"].(float64)
-	cryptoVal := stats["crypto_median"].(float64)
-	
-	if fiatVal > 0 && cryptoVal > 0 {
-		spread = cryptoVal - fiatVal
+	// Scaling by 10000 first is exact, so only the division rounds
+	premium, err := fixed.Mul(crypto-fiat, 10000*fixed.One)
+	if err != nil {
+		return 0, false, err
 	}
-	
-	// Calibration
-	offsetStr := os.Getenv("PRICE_OFFSET")
-	if offsetStr != "" {
-		offset, err := strconv.ParseFloat(offsetStr, 64)
-		if err == nil && offset != 0 { median += offset }
+	bps, err := fixed.Div(premium, fiat)
+	return bps, err == nil, err
+}
+
+// swissquoteQuote reads the best bid/offer for an instrument such as "XAU/USD".
+func swissquoteQuote(instrument string) (Quote, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("https://forex-data-feed.swissquote.com/public-quotes/bboquotes/instrument/" + instrument)
+	if err != nil { return Quote{}, err }
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil { return Quote{}, err }
+	type bbo struct {
+		Bid json.Number `json:"bid"`
+		Ask json.Number `json:"ask"`
+	}
+	var data []struct {
+		Topo   bbo   `json:"topo"`
+		Prices []bbo `json:"spreadProfilePrices"`
+		TS     int64 `json:"ts"`
 	}
-	return median, successCount, stats, spread, nil
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil { return Quote{}, err }
+	if len(data) == 0 { return Quote{}, fmt.Errorf("empty response") }
+	// The first spread profile is the tightest; older payloads only carry topo
+	best := data[0].Topo
+	if len(data[0].Prices) > 0 { best = data[0].Prices[0] }
+	q, err := parseQuote(best.Bid.String(), best.Ask.String(), "")
+	q.Time = unixTime(data[0].TS)
+	return q.normalize(), err
 }
 
-func priceHandler(w http.ResponseWriter, r *http.Request) {
-	start := time.Now()
-	keys := map[string]string{
+func apiKeys() map[string]string {
+	return map[string]string{
 		"GOLDAPI_IO_KEY":  os.Getenv("GOLDAPI_IO_KEY"),
 		"GOLDAPI_COM_KEY": os.Getenv("GOLDAPI_COM_KEY"),
 		"POLYGON_KEY":     os.Getenv("POLYGON_KEY"),
 	}
-	
-	price, sources, stats, spread, err := fetchAllPrices(keys)
+}
+
+func priceHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	price, sources, stats, spread, snapshot, err := currentPrices()
 	latency := time.Since(start).Milliseconds()
 	
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
 		return
 	}
 	
@@ -253,37 +318,64 @@ func priceHandler(w http.ResponseWriter, r *http.Request) {
 	// --- NEW STRUCTURED RESPONSE ---
 	// Prioritizing Fiat (Non-Crypto) as requested
 	
+	// Prices are fixed point (*_e8, scale 1e8); the floats are for display
+	fiat, _ := stats["fiat_median"].(fixed.Price)
+	crypto, _ := stats["crypto_median"].(fixed.Price)
 	response := map[string]interface{}{
 		"fiat": map[string]interface{}{
-			"price":   stats["fiat_median"],
-			"sources": stats["fiat_sources"],
+			"price":    fiat.Float(),
+			"price_e8": fiat,
+			"sources":  stats["fiat_sources"],
 		},
 		"crypto": map[string]interface{}{
-			"price":   stats["crypto_median"],
-			"sources": stats["crypto_sources"],
+			"price":    crypto.Float(),
+			"price_e8": crypto,
+			"sources":  stats["crypto_sources"],
 		},
 		"aggregate": map[string]interface{}{
-			"price":   price,
+			"price":         price.Float(),
+			"price_e8":      price,
 			"total_sources": sources,
 		},
-		"spread":     spread,
-		"latency_ms": latency,
-		"timestamp":  time.Now().Unix(),
+		"scale":       fixed.Scale,
+		"spread":      spread.Float(),
+		"spread_e8":   spread,
+		"price_mode":  priceMode,
+		"quotes":      quoteAges(snapshot),
+		"oldest_quote_age_seconds": oldestQuoteAge(snapshot),
+		"fx":          currentFX(),
+		"calibration": activeCalibration,
+		"latency_ms":  latency,
+		"timestamp":   time.Now().Unix(),
+	}
+	bps, ok, err := spreadBps(stats)
+	if err != nil {
+		http.Error(w, "spread: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if ok {
+		response["spread_bps"], response["spread_bps_e8"] = bps.Float(), bps
 	}
 	
 	json.NewEncoder(w).Encode(response)
 }
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"status":"ok"}`))
-}
-
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+	if err := loadCalibration(); err != nil {
+		log.Fatalf("❌ Calibration: %v", err)
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" { port = DEFAULT_PORT }
 	http.HandleFunc("/price", priceHandler)
 	http.HandleFunc("/health", healthHandler)
-	log.Printf("Aurum Node listening on :%s", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
-}
\ No newline at end of file
+	http.HandleFunc("/ready", readyHandler)
+	startPollers()
+	startFXPollers()
+	listenCfg := listener.FromEnv(":" + port)
+	log.Printf("Aurum Node listening on %s (tls=%v)", listenCfg.Addr, listenCfg.TLSEnabled())
+	log.Fatal(listener.ListenAndServe(listenCfg, nil))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+func TestScrapePrice(t *testing.T) {
+	page := `<span>Volume 1,234.00</span><span data-test="instrument-price-last">4,012.35</span><span>52 wk 5,210.90</span>`
+	tests := []struct {
+		name    string
+		lo, hi  string
+		want    string
+		wantErr bool
+	}{
+		{"default range", "1500", "5000", "4012.35", false},
+		{"raised range", "5000", "9000", "5210.90", false},
+		{"five digits", "10000", "20000", "", true},
+	}
+	for _, tt := range tests {
+		got, err := scrapePrice(page, px(tt.lo), px(tt.hi))
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != px(tt.want)) {
+			t.Errorf("%s: %s, %v", tt.name, got, err)
+		}
+	}
+	if got, err := scrapePrice(`<b>12,650.00</b>`, px("10000"), px("20000")); err != nil || got != px("12650") {
+		t.Errorf("five-digit price: %s, %v", got, err)
+	}
+}
+
+func TestPlausibleRange(t *testing.T) {
+	lo, hi, err := plausibleRange("Investing.com", 1500*fixed.One, 5000*fixed.One)
+	if err != nil || lo != 1500*fixed.One || hi != 5000*fixed.One {
+		t.Fatalf("defaults: %s-%s, %v", lo, hi, err)
+	}
+	t.Setenv("SOURCE_INVESTING_COM_MAX_PRICE", "8000")
+	if _, hi, err := plausibleRange("Investing.com", 1500*fixed.One, 5000*fixed.One); err != nil || hi != 8000*fixed.One {
+		t.Errorf("MAX_PRICE=8000: %s, %v", hi, err)
+	}
+	t.Setenv("SOURCE_INVESTING_COM_MIN_PRICE", "9000")
+	if _, _, err := plausibleRange("Investing.com", 1500*fixed.One, 5000*fixed.One); err == nil {
+		t.Error("MIN_PRICE above MAX_PRICE accepted")
+	}
+	t.Setenv("SOURCE_INVESTING_COM_MIN_PRICE", "cheap")
+	if _, _, err := plausibleRange("Investing.com", 1500*fixed.One, 5000*fixed.One); err == nil {
+		t.Error("unparseable MIN_PRICE accepted")
+	}
+}
+
+func TestSpreadBps(t *testing.T) {
+	tests := []struct {
+		fiat, crypto string
+		want         string
+		wantOK       bool
+	}{
+		{"2650", "2652.65", "10", true},
+		{"2650", "2647.35", "-10", true},
+		{"3000", "3001", "3.33333333", true}, // 3.333333333... rounds down
+		{"3000", "2999", "-3.33333333", true},
+		{"0", "2650", "0", false},
+	}
+	for _, tt := range tests {
+		stats := map[string]interface{}{"fiat_median": px(tt.fiat), "crypto_median": px(tt.crypto)}
+		got, ok, err := spreadBps(stats)
+		if err != nil || ok != tt.wantOK || got != px(tt.want) {
+			t.Errorf("spread %s over %s = %s, %v, %v; want %s", tt.crypto, tt.fiat, got, ok, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+// Fallbacks for sources that do not set Interval / TTL.
+const (
+	defaultPollInterval = 15 * time.Second
+	ttlIntervals        = 3 // a quote survives this many missed polls
+	maxClockSkew        = time.Minute
+)
+
+// sourceEnvPrefix turns "Investing.com" into "SOURCE_INVESTING_COM_".
+func sourceEnvPrefix(name string) string {
+	upper := strings.ToUpper(name)
+	var b strings.Builder
+	for _, r := range upper {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return "SOURCE_" + b.String() + "_"
+}
+
+func envSeconds(key string) (time.Duration, bool) {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return time.Duration(n) * time.Second, true
+	}
+	return 0, false
+}
+
+// sourceSchedule resolves a source's poll interval, cache TTL and maximum
+// quote age, letting SOURCE_<NAME>_INTERVAL_SECONDS, _TTL_SECONDS and
+// _MAX_AGE_SECONDS override them. Every source ends up with a max age.
+func sourceSchedule(s PriceSource) (time.Duration, time.Duration, time.Duration) {
+	interval, ttl, maxAge := s.Interval, s.TTL, s.MaxAge
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	prefix := sourceEnvPrefix(s.Name)
+	if v, ok := envSeconds(prefix + "INTERVAL_SECONDS"); ok {
+		interval = v
+	}
+	if v, ok := envSeconds(prefix + "TTL_SECONDS"); ok {
+		ttl = v
+	}
+	if v, ok := envSeconds(prefix + "MAX_AGE_SECONDS"); ok {
+		maxAge = v
+	}
+	if ttl <= 0 {
+		ttl = ttlIntervals * interval
+	}
+	if maxAge <= 0 {
+		maxAge = ttl
+	}
+	return interval, ttl, maxAge
+}
+
+// startPollers launches one background poller per source. /price only ever
+// reads what they have cached.
+func startPollers() {
+	for _, s := range sources {
+		interval, ttl, maxAge := sourceSchedule(s)
+		limiter := newSourceLimiter(s, interval)
+		registerSource(s, interval, ttl, maxAge, limiter)
+		log.Printf("⏱️  [%s] polling every %s, cached for %s", s.Name, interval, ttl)
+		go pollSource(s, maxAge, limiter)
+	}
+}
+
+// maxPollSleep bounds how long a poller sleeps at once, so budget resets
+// and clock changes are noticed promptly.
+const maxPollSleep = time.Minute
+
+// checkQuoteTime rejects a quote whose upstream timestamp is older than
+// maxAge, which is how a feed that stopped updating but still answers 200
+// shows up, or implausibly far in the future.
+func checkQuoteTime(q Quote, maxAge time.Duration, now time.Time) error {
+	if q.Time == 0 {
+		return nil
+	}
+	age := now.Sub(time.Unix(q.Time, 0))
+	if age < -maxClockSkew {
+		return fmt.Errorf("quote time %s in the future", (-age).Round(time.Second))
+	}
+	if maxAge > 0 && age > maxAge {
+		return fmt.Errorf("stale quote: upstream time %s old (max %s)", age.Round(time.Second), maxAge)
+	}
+	return nil
+}
+
+func pollSource(s PriceSource, maxAge time.Duration, limiter *sourceLimiter) {
+	for {
+		if ok, wait := limiter.allow(time.Now()); !ok {
+			if wait > maxPollSleep {
+				wait = maxPollSleep
+			}
+			time.Sleep(wait)
+			continue
+		}
+		start := time.Now()
+		q, err := s.Fetch(apiKeys())
+		q = q.normalize()
+		price := q.Price(priceMode)
+		if err == nil && price < 1000*fixed.One {
+			err = fmt.Errorf("implausible price %s", price)
+		}
+		if err == nil && q.Bid > 0 && q.Ask < q.Bid {
+			err = fmt.Errorf("crossed quote: bid %s > ask %s", q.Bid, q.Ask)
+		}
+		if err == nil {
+			err = checkQuoteTime(q, maxAge, time.Now())
+		}
+		limiter.record(time.Now(), err)
+		// Log transitions only; a poll every few seconds would drown the log
+		if recordSource(s.Name, q, err, time.Since(start)) {
+			if err != nil {
+				log.Printf("⚠️  [%s] Failed: %v", s.Name, err)
+			} else {
+				log.Printf("✅ [%s] $%s (%dms)", s.Name, price, time.Since(start).Milliseconds())
+			}
+		}
+	}
+}
+
+// quoteAges is the per-source part of the /price response.
+func quoteAges(snapshot []SourceStatus) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(snapshot))
+	for _, st := range snapshot {
+		if st.LastSuccess == 0 {
+			continue
+		}
+		entry := map[string]interface{}{
+			"source":            st.Name,
+			"type":              st.Type,
+			"price":             st.LastPrice.Float(),
+			"price_e8":          st.LastPrice,
+			"quote":             st.Quote,
+			"offset":            activeCalibration.Offset(st.Name),
+			"age_seconds":       st.AgeSeconds,
+			"quote_age_seconds": st.QuoteAgeSeconds,
+			"used":              st.Fresh,
+		}
+		if bps, ok := st.Quote.SpreadBps(); ok {
+			entry["bid_ask_bps"] = bps
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// oldestQuoteAge is the age of the oldest quote that went into the price,
+// by upstream time where the source reports one. The aggregator uses it to
+// enforce freshness end to end.
+func oldestQuoteAge(snapshot []SourceStatus) int64 {
+	var oldest int64
+	for _, st := range snapshot {
+		if st.Fresh && st.QuoteAgeSeconds > oldest {
+			oldest = st.QuoteAgeSeconds
+		}
+	}
+	return oldest
+}
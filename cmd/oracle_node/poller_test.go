@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+func TestSourceEnvPrefix(t *testing.T) {
+	for name, want := range map[string]string{
+		"Investing.com": "SOURCE_INVESTING_COM_",
+		"GoldAPI_IO":    "SOURCE_GOLDAPI_IO_",
+		"FX_ECB":        "SOURCE_FX_ECB_",
+	} {
+		if got := sourceEnvPrefix(name); got != want {
+			t.Errorf("sourceEnvPrefix(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSourceSchedule(t *testing.T) {
+	tests := []struct {
+		name                  string
+		source                PriceSource
+		env                   map[string]string
+		wantInterval, wantTTL time.Duration
+	}{
+		{"defaults", PriceSource{Name: "S"}, nil, defaultPollInterval, ttlIntervals * defaultPollInterval},
+		{"TTL follows the interval", PriceSource{Name: "S", Interval: 10 * time.Second}, nil, 10 * time.Second, 30 * time.Second},
+		{"explicit TTL", PriceSource{Name: "S", Interval: 10 * time.Second, TTL: time.Minute}, nil, 10 * time.Second, time.Minute},
+		{"env overrides", PriceSource{Name: "S", Interval: 10 * time.Second},
+			map[string]string{"SOURCE_S_INTERVAL_SECONDS": "30", "SOURCE_S_TTL_SECONDS": "45"}, 30 * time.Second, 45 * time.Second},
+		{"env interval moves the default TTL", PriceSource{Name: "S", Interval: 10 * time.Second},
+			map[string]string{"SOURCE_S_INTERVAL_SECONDS": "20"}, 20 * time.Second, 60 * time.Second},
+		{"invalid env ignored", PriceSource{Name: "S", Interval: 10 * time.Second},
+			map[string]string{"SOURCE_S_INTERVAL_SECONDS": "-5", "SOURCE_S_TTL_SECONDS": "soon"}, 10 * time.Second, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			interval, ttl, _ := sourceSchedule(tt.source)
+			if interval != tt.wantInterval || ttl != tt.wantTTL {
+				t.Errorf("got interval %s, TTL %s; want %s, %s", interval, ttl, tt.wantInterval, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestCachedQuoteExpiresAfterTTL(t *testing.T) {
+	withSources(t)
+	addSource(t, "A", "fiat", time.Minute, time.Hour)
+	recordSource("A", Quote{Last: 2650 * fixed.One}, nil, time.Millisecond)
+
+	price, n, _, _, _, err := currentPrices()
+	if err != nil || n != 1 || price != 2650*fixed.One {
+		t.Fatalf("fresh cache: %s from %d sources, %v", price, n, err)
+	}
+
+	// Polls keep failing: the cached quote is served until it is TTL old
+	sourceHealth.Lock()
+	sourceHealth.sources["A"].lastSuccess = time.Now().Add(-59 * time.Second)
+	sourceHealth.Unlock()
+	if _, n, _, _, _, err := currentPrices(); err != nil || n != 1 {
+		t.Fatalf("cache within TTL: %d sources, %v", n, err)
+	}
+	sourceHealth.Lock()
+	sourceHealth.sources["A"].lastSuccess = time.Now().Add(-61 * time.Second)
+	sourceHealth.Unlock()
+	if _, _, _, _, _, err := currentPrices(); err == nil {
+		t.Fatal("quote older than its TTL was served")
+	}
+}
+
+func TestSourceScheduleMaxAge(t *testing.T) {
+	s := PriceSource{Name: "S", Interval: 10 * time.Second}
+	if _, ttl, maxAge := sourceSchedule(s); maxAge != ttl {
+		t.Errorf("source without MaxAge: max age %s, want the TTL %s", maxAge, ttl)
+	}
+	s.MaxAge = 2 * time.Minute
+	if _, _, maxAge := sourceSchedule(s); maxAge != 2*time.Minute {
+		t.Errorf("max age %s", maxAge)
+	}
+	t.Setenv("SOURCE_S_MAX_AGE_SECONDS", "300")
+	if _, _, maxAge := sourceSchedule(s); maxAge != 5*time.Minute {
+		t.Errorf("env max age %s", maxAge)
+	}
+	for _, s := range sources {
+		if s.MaxAge <= 0 {
+			t.Errorf("%s has no MaxAge", s.Name)
+		}
+	}
+}
+
+func TestCheckQuoteTime(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name    string
+		at      int64
+		wantErr bool
+	}{
+		{"no upstream time", 0, false},
+		{"recent", now.Add(-time.Minute).Unix(), false},
+		{"older than max age", now.Add(-3 * time.Minute).Unix(), true},
+		{"within clock skew", now.Add(30 * time.Second).Unix(), false},
+		{"in the future", now.Add(2 * maxClockSkew).Unix(), true},
+	}
+	for _, tt := range tests {
+		if err := checkQuoteTime(Quote{Time: tt.at}, 2*time.Minute, now); (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v", tt.name, err)
+		}
+	}
+}
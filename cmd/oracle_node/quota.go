@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	maxBackoff       = 10 * time.Minute
+	breakerThreshold = 5 // consecutive failures before the breaker opens
+	breakerCooldown  = 5 * time.Minute
+	maxCooldown      = time.Hour
+)
+
+// RateLimitedError is returned by a Fetch that got HTTP 429.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limited (retry after %s)", e.RetryAfter)
+}
+
+// checkStatus turns a non-200 upstream response into an error, honouring
+// Retry-After (delta-seconds or HTTP date) on 429.
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	wait := time.Minute
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			wait = time.Duration(secs) * time.Second
+		} else if at, err := http.ParseTime(v); err == nil {
+			wait = time.Until(at)
+		}
+	}
+	return &RateLimitedError{RetryAfter: wait}
+}
+
+// LimiterStatus is the budget and breaker part of a source's health.
+type LimiterStatus struct {
+	DailyBudget   int    `json:"daily_budget,omitempty"`
+	UsedToday     int    `json:"used_today"`
+	MonthlyBudget int    `json:"monthly_budget,omitempty"`
+	UsedThisMonth int    `json:"used_this_month"`
+	Failures      int    `json:"consecutive_failures"`
+	Breaker       string `json:"breaker"` // closed, open or half-open
+	NextAttempt   int64  `json:"next_attempt,omitempty"`
+	Blocked       string `json:"blocked,omitempty"` // why the next poll is held back
+}
+
+// sourceLimiter paces one source: it spreads a metered budget over its
+// period, backs off exponentially on errors, opens a circuit breaker after
+// repeated failures and obeys Retry-After.
+type sourceLimiter struct {
+	mu       sync.Mutex
+	name     string
+	interval time.Duration
+
+	dailyBudget, monthlyBudget int
+	day, month                 string
+	usedDay, usedMonth         int
+
+	failures    int
+	open        bool
+	cooldown    time.Duration
+	nextAttempt time.Time
+	blocked     string
+}
+
+func newSourceLimiter(s PriceSource, interval time.Duration) *sourceLimiter {
+	prefix := sourceEnvPrefix(s.Name)
+	l := &sourceLimiter{name: s.Name, interval: interval, cooldown: breakerCooldown}
+	// Budgets come from the provider plan: SOURCE_<NAME>_DAILY_BUDGET / _MONTHLY_BUDGET
+	l.dailyBudget, _ = strconv.Atoi(os.Getenv(prefix + "DAILY_BUDGET"))
+	l.monthlyBudget, _ = strconv.Atoi(os.Getenv(prefix + "MONTHLY_BUDGET"))
+	quotaStore.restore(l)
+	return l
+}
+
+// rollover resets counters when the UTC day or month changes. Caller holds mu.
+func (l *sourceLimiter) rollover(now time.Time) {
+	now = now.UTC()
+	if day := now.Format("2006-01-02"); day != l.day {
+		l.day, l.usedDay = day, 0
+	}
+	if month := now.Format("2006-01"); month != l.month {
+		l.month, l.usedMonth = month, 0
+	}
+}
+
+// pacedInterval stretches the poll interval so the remaining budget lasts
+// until it resets. Caller holds mu.
+func (l *sourceLimiter) pacedInterval(now time.Time) time.Duration {
+	interval := l.interval
+	now = now.UTC()
+	spread := func(budget, used int, reset time.Time) {
+		if budget <= 0 {
+			return
+		}
+		remaining := budget - used
+		if remaining <= 0 {
+			return
+		}
+		if paced := reset.Sub(now) / time.Duration(remaining); paced > interval {
+			interval = paced
+		}
+	}
+	y, m, d := now.Date()
+	spread(l.dailyBudget, l.usedDay, time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC))
+	spread(l.monthlyBudget, l.usedMonth, time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC))
+	return interval
+}
+
+// allow reports whether a call may be made now, and if not, how long to wait.
+func (l *sourceLimiter) allow(now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rollover(now)
+	if now.Before(l.nextAttempt) {
+		return false, l.nextAttempt.Sub(now)
+	}
+	if l.dailyBudget > 0 && l.usedDay >= l.dailyBudget {
+		l.blocked = "daily budget exhausted"
+		y, m, d := now.UTC().Date()
+		return false, time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC).Sub(now)
+	}
+	if l.monthlyBudget > 0 && l.usedMonth >= l.monthlyBudget {
+		l.blocked = "monthly budget exhausted"
+		y, m, _ := now.UTC().Date()
+		return false, time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC).Sub(now)
+	}
+	l.blocked = ""
+	l.usedDay++
+	l.usedMonth++
+	return true, 0
+}
+
+// record updates backoff and breaker state after a call and schedules the next one.
+func (l *sourceLimiter) record(now time.Time, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	defer quotaStore.save(l)
+
+	if err == nil {
+		if l.open {
+			log.Printf("🔌 [%s] circuit closed", l.name)
+		}
+		l.failures, l.open, l.cooldown = 0, false, breakerCooldown
+		l.nextAttempt = now.Add(l.pacedInterval(now))
+		return
+	}
+
+	l.failures++
+	var wait time.Duration
+	switch {
+	case l.open:
+		// Half-open trial failed: stay open for longer
+		l.cooldown *= 2
+		if l.cooldown > maxCooldown {
+			l.cooldown = maxCooldown
+		}
+		wait = l.cooldown
+	case l.failures >= breakerThreshold:
+		l.open = true
+		wait = l.cooldown
+		log.Printf("🔌 [%s] circuit open after %d failures, retrying in %s", l.name, l.failures, wait)
+	default:
+		wait = l.interval << uint(l.failures)
+		if wait > maxBackoff || wait <= 0 {
+			wait = maxBackoff
+		}
+	}
+	var limited *RateLimitedError
+	if errors.As(err, &limited) && limited.RetryAfter > wait {
+		wait = limited.RetryAfter
+	}
+	if paced := l.pacedInterval(now); paced > wait {
+		wait = paced
+	}
+	l.nextAttempt = now.Add(wait)
+}
+
+func (l *sourceLimiter) status() LimiterStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	st := LimiterStatus{
+		DailyBudget:   l.dailyBudget,
+		UsedToday:     l.usedDay,
+		MonthlyBudget: l.monthlyBudget,
+		UsedThisMonth: l.usedMonth,
+		Failures:      l.failures,
+		Breaker:       "closed",
+		Blocked:       l.blocked,
+	}
+	if !l.nextAttempt.IsZero() {
+		st.NextAttempt = l.nextAttempt.Unix()
+	}
+	if l.open {
+		st.Breaker = "open"
+		if !time.Now().Before(l.nextAttempt) {
+			st.Breaker = "half-open"
+		}
+	}
+	return st
+}
+
+// --- Persistence ---
+
+// quotaStore keeps usage counters in QUOTA_STATE_FILE so a restart does not
+// hand a metered source a fresh budget. Without the variable, counts live in memory.
+var quotaStore = &quotaFile{path: os.Getenv("QUOTA_STATE_FILE"), state: map[string]quotaUsage{}}
+
+type quotaUsage struct {
+	Day       string `json:"day"`
+	UsedDay   int    `json:"used_day"`
+	Month     string `json:"month"`
+	UsedMonth int    `json:"used_month"`
+}
+
+type quotaFile struct {
+	path   string
+	mu     sync.Mutex
+	loaded bool
+	state  map[string]quotaUsage
+}
+
+func (q *quotaFile) restore(l *sourceLimiter) {
+	if q.path == "" {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.loaded {
+		q.loaded = true
+		if data, err := os.ReadFile(q.path); err == nil {
+			if err := json.Unmarshal(data, &q.state); err != nil {
+				log.Printf("⚠️  Ignoring corrupt %s: %v", q.path, err)
+			}
+		}
+	}
+	u := q.state[l.name]
+	l.day, l.usedDay, l.month, l.usedMonth = u.Day, u.UsedDay, u.Month, u.UsedMonth
+}
+
+// save is called with l.mu held.
+func (q *quotaFile) save(l *sourceLimiter) {
+	if q.path == "" {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.state[l.name] = quotaUsage{Day: l.day, UsedDay: l.usedDay, Month: l.month, UsedMonth: l.usedMonth}
+	data, _ := json.Marshal(q.state)
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		log.Printf("⚠️  Quota state: %v", err)
+		return
+	}
+	os.Rename(tmp, q.path)
+}
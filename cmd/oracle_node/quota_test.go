@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCheckStatus(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		wantErr    bool
+		wantWait   time.Duration // for 429s
+	}{
+		{"ok", http.StatusOK, "", false, 0},
+		{"server error", http.StatusInternalServerError, "", true, 0},
+		{"429 without Retry-After", http.StatusTooManyRequests, "", true, time.Minute},
+		{"429 with seconds", http.StatusTooManyRequests, "120", true, 2 * time.Minute},
+		{"429 with a date", http.StatusTooManyRequests, now.Add(5 * time.Minute).UTC().Format(http.TimeFormat), true, 5 * time.Minute},
+		{"429 with garbage", http.StatusTooManyRequests, "later", true, time.Minute},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		if tt.retryAfter != "" {
+			resp.Header.Set("Retry-After", tt.retryAfter)
+		}
+		err := checkStatus(resp)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		var limited *RateLimitedError
+		if errors.As(err, &limited) != (tt.wantWait > 0) {
+			t.Errorf("%s: rate limited = %v", tt.name, err)
+			continue
+		}
+		// HTTP dates have one-second resolution
+		if limited != nil && (limited.RetryAfter < tt.wantWait-2*time.Second || limited.RetryAfter > tt.wantWait) {
+			t.Errorf("%s: retry after %s, want %s", tt.name, limited.RetryAfter, tt.wantWait)
+		}
+	}
+}
+
+func newTestLimiter(interval time.Duration) *sourceLimiter {
+	return &sourceLimiter{name: "test", interval: interval, cooldown: breakerCooldown}
+}
+
+func TestPacedInterval(t *testing.T) {
+	noon := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name             string
+		daily, monthly   int
+		usedDay, usedMon int
+		want             time.Duration
+	}{
+		{"no budget", 0, 0, 0, 0, 10 * time.Second},
+		// 12 hours left for 72 calls
+		{"daily budget", 100, 0, 28, 28, 10 * time.Minute},
+		{"budget allows the base interval", 100000, 0, 0, 0, 10 * time.Second},
+		// 21.5 days left for 516 calls
+		{"monthly budget", 0, 1000, 0, 484, time.Hour},
+		{"exhausted budget leaves pacing to allow", 100, 0, 100, 100, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		l := newTestLimiter(10 * time.Second)
+		l.dailyBudget, l.monthlyBudget = tt.daily, tt.monthly
+		l.rollover(noon)
+		l.usedDay, l.usedMonth = tt.usedDay, tt.usedMon
+		if got := l.pacedInterval(noon); got != tt.want {
+			t.Errorf("%s: paced interval %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAllowBudget(t *testing.T) {
+	now := time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)
+	l := newTestLimiter(time.Second)
+	l.dailyBudget = 2
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow(now); !ok {
+			t.Fatalf("call %d refused within budget", i+1)
+		}
+	}
+	ok, wait := l.allow(now)
+	if ok || wait != time.Hour || l.status().Blocked != "daily budget exhausted" {
+		t.Fatalf("over budget: ok %v, wait %s, status %+v", ok, wait, l.status())
+	}
+	// The budget resets with the UTC day
+	if ok, _ := l.allow(now.Add(time.Hour)); !ok {
+		t.Fatal("budget not reset the next day")
+	}
+}
+
+func TestRecordBackoffAndBreaker(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(10 * time.Second)
+	fail := errors.New("status 500")
+	next := func() time.Duration { return l.nextAttempt.Sub(now) }
+
+	// Exponential backoff below the breaker threshold
+	for i, want := range []time.Duration{20 * time.Second, 40 * time.Second, 80 * time.Second, 160 * time.Second} {
+		l.record(now, fail)
+		if next() != want || l.open {
+			t.Fatalf("failure %d: next attempt in %s (open %v), want %s", i+1, next(), l.open, want)
+		}
+	}
+	l.record(now, fail)
+	if !l.open || next() != breakerCooldown || l.status().Failures != breakerThreshold {
+		t.Fatalf("breaker after %d failures: open %v, next %s", breakerThreshold, l.open, next())
+	}
+	if st := l.status(); st.Breaker != "open" {
+		t.Errorf("breaker reported %q before its cooldown", st.Breaker)
+	}
+
+	// The half-open trial fails: the cooldown doubles, up to maxCooldown
+	l.record(now, fail)
+	if next() != 2*breakerCooldown {
+		t.Fatalf("failed trial: next attempt in %s", next())
+	}
+	for i := 0; i < 10; i++ {
+		l.record(now, fail)
+	}
+	if next() != maxCooldown {
+		t.Fatalf("cooldown grew to %s, want at most %s", next(), maxCooldown)
+	}
+
+	// A success closes the breaker and resets the backoff
+	l.record(now, nil)
+	if l.open || l.failures != 0 || next() != 10*time.Second || l.cooldown != breakerCooldown {
+		t.Fatalf("after success: open %v, failures %d, next %s", l.open, l.failures, next())
+	}
+	if st := l.status(); st.Breaker != "closed" {
+		t.Errorf("breaker reported %q after a success", st.Breaker)
+	}
+}
+
+func TestRecordRetryAfter(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(10 * time.Second)
+	l.record(now, &RateLimitedError{RetryAfter: 15 * time.Minute})
+	if wait := l.nextAttempt.Sub(now); wait != 15*time.Minute {
+		t.Errorf("Retry-After longer than the backoff: next attempt in %s", wait)
+	}
+	l = newTestLimiter(10 * time.Second)
+	l.record(now, &RateLimitedError{RetryAfter: time.Second})
+	if wait := l.nextAttempt.Sub(now); wait != 20*time.Second {
+		t.Errorf("Retry-After shorter than the backoff: next attempt in %s", wait)
+	}
+	if ok, wait := l.allow(now); ok || wait != 20*time.Second {
+		t.Errorf("allow before the next attempt: %v, %s", ok, wait)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+// Quote is what a source reports, in fixed point (see pkg/fixed). Fields a
+// source does not provide stay 0.
+type Quote struct {
+	Bid  fixed.Price `json:"bid_e8,omitempty"`
+	Ask  fixed.Price `json:"ask_e8,omitempty"`
+	Mid  fixed.Price `json:"mid_e8,omitempty"`
+	Last fixed.Price `json:"last_e8,omitempty"`
+	Time int64       `json:"time,omitempty"` // source timestamp, unix seconds
+}
+
+// normalize fills Mid from a valid bid/ask pair.
+func (q Quote) normalize() Quote {
+	if q.Mid == 0 && q.Bid > 0 && q.Ask >= q.Bid {
+		q.Mid = fixed.Midpoint(q.Bid, q.Ask)
+	}
+	return q
+}
+
+// Price picks the value to aggregate under mode, falling back to the other
+// one when the source does not report it.
+func (q Quote) Price(mode string) fixed.Price {
+	if mode == "last" {
+		if q.Last > 0 {
+			return q.Last
+		}
+		return q.Mid
+	}
+	if q.Mid > 0 {
+		return q.Mid
+	}
+	return q.Last
+}
+
+// SpreadBps is the source's bid/ask spread in basis points of mid.
+func (q Quote) SpreadBps() (float64, bool) {
+	if q.Bid <= 0 || q.Ask < q.Bid || q.Mid <= 0 {
+		return 0, false
+	}
+	return math.Round((q.Ask-q.Bid).Float()/q.Mid.Float()*10000*100) / 100, true
+}
+
+// priceMode reads PRICE_MODE: "mid" (default) or "last".
+var priceMode = func() string {
+	switch m := strings.ToLower(os.Getenv("PRICE_MODE")); m {
+	case "", "mid":
+		return "mid"
+	case "last":
+		return "last"
+	default:
+		fmt.Fprintf(os.Stderr, "⚠️  Unknown PRICE_MODE %q, using mid\n", m)
+		return "mid"
+	}
+}()
+
+// parseQuote parses the decimal strings sources send (exchange strings or
+// json.Number); empty fields are left 0.
+func parseQuote(bid, ask, last string) (Quote, error) {
+	var q Quote
+	for _, f := range []struct {
+		s   string
+		dst *fixed.Price
+	}{{bid, &q.Bid}, {ask, &q.Ask}, {last, &q.Last}} {
+		if f.s == "" {
+			continue
+		}
+		v, err := fixed.Parse(f.s)
+		if err != nil {
+			return Quote{}, err
+		}
+		*f.dst = v
+	}
+	return q, nil
+}
+
+// unixTime accepts seconds or milliseconds.
+func unixTime(v int64) int64 {
+	if v > 1e12 {
+		return v / 1000
+	}
+	return v
+}
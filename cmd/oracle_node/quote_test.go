@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+func TestParseQuote(t *testing.T) {
+	q, err := parseQuote("2650.10", "2650.50", "")
+	if err != nil || q.Bid != px("2650.10") || q.Ask != px("2650.50") || q.Last != 0 {
+		t.Fatalf("parseQuote = %+v, %v", q, err)
+	}
+	if _, err := parseQuote("2650.10", "n/a", ""); err == nil {
+		t.Error("unparseable ask accepted")
+	}
+}
+
+func TestQuotePrice(t *testing.T) {
+	bid, ask, last := px("2650.10"), px("2650.50"), px("2651")
+	mid := px("2650.30")
+	tests := []struct {
+		name     string
+		q        Quote
+		wantMid  fixed.Price
+		wantLast fixed.Price
+	}{
+		{"bid, ask and last", Quote{Bid: bid, Ask: ask, Last: last}, mid, last},
+		{"bid and ask only", Quote{Bid: bid, Ask: ask}, mid, mid},
+		{"last only", Quote{Last: last}, last, last},
+		// A crossed pair has no mid; pollSource refuses it
+		{"crossed", Quote{Bid: ask, Ask: bid, Last: last}, last, last},
+		{"bid without ask", Quote{Bid: bid, Last: last}, last, last},
+	}
+	for _, tt := range tests {
+		q := tt.q.normalize()
+		if got := q.Price("mid"); got != tt.wantMid {
+			t.Errorf("%s: mid mode %s, want %s", tt.name, got, tt.wantMid)
+		}
+		if got := q.Price("last"); got != tt.wantLast {
+			t.Errorf("%s: last mode %s, want %s", tt.name, got, tt.wantLast)
+		}
+	}
+}
+
+func TestQuoteSpreadBps(t *testing.T) {
+	q := Quote{Bid: px("2650"), Ask: px("2652.65")}.normalize()
+	if bps, ok := q.SpreadBps(); !ok || bps != 10 {
+		t.Errorf("spread = %v, %v; want 10 bps", bps, ok)
+	}
+	if _, ok := (Quote{Last: px("2650")}).normalize().SpreadBps(); ok {
+		t.Error("spread reported without bid/ask")
+	}
+}
+
+func TestUnixTime(t *testing.T) {
+	if got := unixTime(1700000000123); got != 1700000000 {
+		t.Errorf("milliseconds: %d", got)
+	}
+	if got := unixTime(1700000000); got != 1700000000 {
+		t.Errorf("seconds: %d", got)
+	}
+}
+
+// px parses a decimal test price.
+func px(s string) fixed.Price {
+	p, err := fixed.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
@@ -0,0 +1,270 @@
+// signer - standalone block signing daemon for the AURUM aggregator.
+// Holds the node key outside the internet-facing process, refuses to sign
+// two different block headers at the same height, and only signs validator
+// change approvals the operator has listed.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"aurum-oracle/pkg/keystore"
+	"aurum-oracle/pkg/listener"
+	"aurum-oracle/pkg/remotesigner"
+)
+
+// signState is the double-sign guard: the last header this key signed.
+// It is persisted before a signature ever leaves the daemon.
+type signState struct {
+	Height      int64  `json:"height"`
+	MessageHash string `json:"message_hash"`
+	Signature   string `json:"signature"`
+}
+
+type Daemon struct {
+	key       ed25519.PrivateKey
+	pubHex    string
+	statePath string
+	allowed   map[string]bool // client certificate CNs; empty allows any verified client
+	// approvalsPath lists, one per line, the approval messages the operator
+	// agreed to sign; read on every request. Empty refuses all approvals
+	approvalsPath string
+
+	mu    sync.Mutex
+	state *signState
+}
+
+func NewDaemon(key ed25519.PrivateKey, statePath string, allowedCNs []string, approvalsPath string) (*Daemon, error) {
+	d := &Daemon{
+		key:           key,
+		pubHex:        hex.EncodeToString(key.Public().(ed25519.PublicKey)),
+		statePath:     statePath,
+		allowed:       map[string]bool{},
+		approvalsPath: approvalsPath,
+	}
+	for _, cn := range allowedCNs {
+		if cn = strings.TrimSpace(cn); cn != "" {
+			d.allowed[cn] = true
+		}
+	}
+	data, err := os.ReadFile(statePath)
+	switch {
+	case os.IsNotExist(err):
+		log.Printf("⚠️  No sign state at %s: starting fresh", statePath)
+	case err != nil:
+		return nil, err
+	default:
+		var st signState
+		// A damaged guard could let us double-sign; make the operator look at it
+		if err := json.Unmarshal(data, &st); err != nil {
+			return nil, fmt.Errorf("corrupt sign state %s: %w", statePath, err)
+		}
+		d.state = &st
+		log.Printf("🛡️  Last signed height %d", st.Height)
+	}
+	return d, nil
+}
+
+// signHeader enforces monotonic heights: a header below the last signed
+// height is refused, and at the same height only the identical header is
+// (re)signed, returning the stored signature. A height is never released;
+// the aggregator re-proposes the block it signed until it lands.
+func (d *Daemon) signHeader(height int64, msg []byte) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sum := sha256.Sum256(msg)
+	msgHash := hex.EncodeToString(sum[:])
+	if d.state != nil {
+		switch {
+		case height < d.state.Height:
+			return "", fmt.Errorf("height %d is below last signed height %d", height, d.state.Height)
+		case height == d.state.Height && msgHash != d.state.MessageHash:
+			return "", fmt.Errorf("already signed a different header at height %d", height)
+		case height == d.state.Height:
+			return d.state.Signature, nil
+		}
+	}
+
+	next := &signState{
+		Height:      height,
+		MessageHash: msgHash,
+		Signature:   hex.EncodeToString(ed25519.Sign(d.key, msg)),
+	}
+	if err := d.persist(next); err != nil {
+		return "", fmt.Errorf("persist sign state: %w", err)
+	}
+	d.state = next
+	return next.Signature, nil
+}
+
+// approvalListed reports whether the operator listed msg in approvalsPath.
+// Whoever controls the aggregator host can ask for any approval, so the
+// decision rests with a file only the signer host's operator edits.
+func (d *Daemon) approvalListed(msg []byte) (bool, error) {
+	if d.approvalsPath == "" {
+		return false, nil
+	}
+	data, err := os.ReadFile(d.approvalsPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == string(msg) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (d *Daemon) persist(st *signState) error {
+	data, _ := json.Marshal(st)
+	tmp, err := os.CreateTemp(filepath.Dir(d.statePath), ".signstate-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+	return os.Rename(tmp.Name(), d.statePath)
+}
+
+func (d *Daemon) authorized(r *http.Request) bool {
+	if r.TLS == nil || len(d.allowed) == 0 {
+		// Unix socket (guarded by file permissions) or any CA-verified client
+		return true
+	}
+	cn, ok := listener.PeerCommonName(r)
+	return ok && d.allowed[cn]
+}
+
+func (d *Daemon) handlePubkey(w http.ResponseWriter, r *http.Request) {
+	if !d.authorized(r) {
+		http.Error(w, "client not allowed", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(remotesigner.SignResponse{Pubkey: d.pubHex})
+}
+
+func (d *Daemon) handleSign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	if !d.authorized(r) {
+		http.Error(w, "client not allowed", http.StatusForbidden)
+		return
+	}
+	var req remotesigner.SignRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	var sig string
+	if height, ok := remotesigner.ParseHeaderMessage(req.Message); ok {
+		var err error
+		if sig, err = d.signHeader(height, req.Message); err != nil {
+			log.Printf("🚨 Refused header at height %d: %v", height, err)
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Printf("✍️  Signed header at height %d", height)
+	} else if remotesigner.IsApprovalMessage(req.Message) {
+		listed, err := d.approvalListed(req.Message)
+		if err != nil {
+			log.Printf("⚠️  Approvals file: %v", err)
+			http.Error(w, "approvals file unreadable", http.StatusServiceUnavailable)
+			return
+		}
+		if !listed {
+			log.Printf("🚨 Refused unlisted validator change approval: %s", req.Message)
+			http.Error(w, "approval not listed in SIGNER_APPROVALS_FILE", http.StatusForbidden)
+			return
+		}
+		sig = hex.EncodeToString(ed25519.Sign(d.key, req.Message))
+		log.Printf("✍️  Signed validator change approval: %s", req.Message)
+	} else {
+		http.Error(w, "not an AURUM header or approval message", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(remotesigner.SignResponse{Pubkey: d.pubHex, Signature: sig})
+}
+
+// serveUnix listens on a socket only the daemon's user can open.
+func serveUnix(path string, handler http.Handler) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return err
+	}
+	return http.Serve(ln, handler)
+}
+
+func main() {
+	keyPath := os.Getenv("SIGNER_KEY_PATH")
+	if keyPath == "" {
+		log.Fatal("❌ SIGNER_KEY_PATH is required")
+	}
+	statePath := os.Getenv("SIGNER_STATE_PATH")
+	if statePath == "" {
+		statePath = keyPath + ".state"
+	}
+
+	key, encrypted, err := keystore.ReadFile(keyPath)
+	if err != nil {
+		log.Fatalf("❌ Signing key: %v", err)
+	}
+	if !encrypted {
+		log.Printf("⚠️  %s is an unencrypted key", keyPath)
+	}
+	daemon, err := NewDaemon(key, statePath, strings.Split(os.Getenv("SIGNER_ALLOWED_CNS"), ","), os.Getenv("SIGNER_APPROVALS_FILE"))
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(remotesigner.PubkeyPath, daemon.handlePubkey)
+	mux.HandleFunc(remotesigner.SignPath, daemon.handleSign)
+	log.Printf("🔑 Aurum Signer for %s", daemon.pubHex)
+
+	if socket := os.Getenv("SIGNER_SOCKET"); socket != "" {
+		log.Printf("Listening on unix:%s", socket)
+		log.Fatal(serveUnix(socket, mux))
+	}
+	listenCfg := listener.FromEnv(":7300")
+	if !listenCfg.TLSEnabled() || listenCfg.ClientAuth != "require" {
+		log.Fatal("❌ TCP mode requires TLS with TLS_CLIENT_AUTH=require (or set SIGNER_SOCKET)")
+	}
+	log.Printf("Listening on %s (mTLS)", listenCfg.Addr)
+	log.Fatal(listener.ListenAndServe(listenCfg, mux))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"aurum-oracle/pkg/remotesigner"
+)
+
+func newTestDaemon(t *testing.T, dir, approvals string) *Daemon {
+	t.Helper()
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+	d, err := NewDaemon(key, filepath.Join(dir, "state"), nil, approvals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestSignHeaderNeverReleasesAHeight(t *testing.T) {
+	dir := t.TempDir()
+	d := newTestDaemon(t, dir, "")
+
+	first, err := d.signHeader(5, []byte("header-5-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := d.signHeader(5, []byte("header-5-a"))
+	if err != nil || again != first {
+		t.Fatalf("identical header re-signed as %q, %v", again, err)
+	}
+	if _, err := d.signHeader(5, []byte("header-5-b")); err == nil {
+		t.Fatal("signed a second header at the same height")
+	}
+	if _, err := d.signHeader(4, []byte("header-4")); err == nil {
+		t.Fatal("signed below the last signed height")
+	}
+
+	// The guard survives a restart
+	d = newTestDaemon(t, dir, "")
+	if _, err := d.signHeader(5, []byte("header-5-b")); err == nil {
+		t.Fatal("signed a second header at the same height after a restart")
+	}
+	if sig, err := d.signHeader(5, []byte("header-5-a")); err != nil || sig != first {
+		t.Fatalf("identical header after a restart: %q, %v", sig, err)
+	}
+	if _, err := d.signHeader(6, []byte("header-6")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewDaemonRefusesCorruptState(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "state"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+	if _, err := NewDaemon(key, filepath.Join(dir, "state"), nil, ""); err == nil {
+		t.Fatal("corrupt sign state accepted")
+	}
+}
+
+func TestApprovalsNeedOperatorListing(t *testing.T) {
+	dir := t.TempDir()
+	listed := "AURUM|validator-change|v1|abc|add|" + strings.Repeat("a", 64) + "||2"
+	unlisted := "AURUM|validator-change|v1|abc|remove|" + strings.Repeat("b", 64) + "||1"
+	approvals := filepath.Join(dir, "approvals")
+
+	sign := func(d *Daemon, msg string) int {
+		body, _ := json.Marshal(remotesigner.SignRequest{Message: []byte(msg)})
+		w := httptest.NewRecorder()
+		d.handleSign(w, httptest.NewRequest(http.MethodPost, remotesigner.SignPath, bytes.NewReader(body)))
+		return w.Code
+	}
+
+	if code := sign(newTestDaemon(t, dir, ""), listed); code != http.StatusForbidden {
+		t.Errorf("no approvals file configured: status %d", code)
+	}
+	d := newTestDaemon(t, dir, approvals)
+	if code := sign(d, listed); code != http.StatusForbidden {
+		t.Errorf("approvals file missing: status %d", code)
+	}
+	if err := os.WriteFile(approvals, []byte("# pending changes\n  "+listed+"  \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if code := sign(d, listed); code != http.StatusOK {
+		t.Errorf("listed approval: status %d", code)
+	}
+	if code := sign(d, unlisted); code != http.StatusForbidden {
+		t.Errorf("unlisted approval: status %d", code)
+	}
+	if code := sign(d, "anything else"); code != http.StatusBadRequest {
+		t.Errorf("unknown message: status %d", code)
+	}
+}
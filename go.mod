@@ -1,3 +1,5 @@
 module aurum-oracle
 
 go 1.23.1
+
+require golang.org/x/crypto v0.40.0
@@ -0,0 +1,157 @@
+// Package calibration defines signed per-source price calibration for AURUM
+// oracle nodes. Offsets only take effect when signed by a trusted key, and
+// every price carries the calibration it was computed with.
+package calibration
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Calibration is a set of additive per-source bias corrections (USD/oz).
+type Calibration struct {
+	Version int                `json:"version"`
+	Offsets map[string]float64 `json:"offsets"`
+	SetBy   string             `json:"set_by"`
+	SetAt   int64              `json:"set_at"` // unix seconds
+	Reason  string             `json:"reason,omitempty"`
+}
+
+// Signed is the calibration file format and what /price reports.
+type Signed struct {
+	Calibration Calibration `json:"calibration"`
+	Signer      string      `json:"signer"`    // hex ed25519 public key
+	Signature   string      `json:"signature"` // hex, over Message()
+}
+
+// Message is the canonical byte string a calibration is signed over:
+// AURUM|calibration|v1|version|set_at|len:set_by|len:reason|name=offset;...
+// with the free-text fields prefixed by their byte length (so no choice of
+// set_by or reason can shift the other fields), sources sorted by name and
+// offsets in shortest decimal form.
+func (c Calibration) Message() []byte {
+	names := make([]string, 0, len(c.Offsets))
+	for name := range c.Offsets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + strconv.FormatFloat(c.Offsets[name], 'f', -1, 64)
+	}
+	return []byte(fmt.Sprintf("AURUM|calibration|v1|%d|%d|%d:%s|%d:%s|%s",
+		c.Version, c.SetAt, len(c.SetBy), c.SetBy, len(c.Reason), c.Reason, strings.Join(pairs, ";")))
+}
+
+// Digest identifies a calibration in the ledger.
+func (c Calibration) Digest() string {
+	h := sha256.Sum256(c.Message())
+	return hex.EncodeToString(h[:])
+}
+
+func Sign(c Calibration, key ed25519.PrivateKey) Signed {
+	return Signed{
+		Calibration: c,
+		Signer:      hex.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Signature:   hex.EncodeToString(ed25519.Sign(key, c.Message())),
+	}
+}
+
+// Verify checks the signature and that the signer is one of the trusted hex
+// keys. It fails closed: with no trusted keys, only a calibration whose
+// offsets are all zero is accepted.
+func (s Signed) Verify(trusted []string) error {
+	if err := s.VerifySignature(); err != nil {
+		return err
+	}
+	for _, k := range trusted {
+		if strings.EqualFold(strings.TrimSpace(k), s.Signer) {
+			return nil
+		}
+	}
+	if len(trusted) == 0 {
+		for name, v := range s.Calibration.Offsets {
+			if v != 0 {
+				return fmt.Errorf("no calibration signers configured: refusing offset %v for %s", v, name)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("calibration signer %s is not trusted", s.Signer)
+}
+
+// VerifySignature checks the calibration is well formed and signed by
+// Signer, whoever that is.
+func (s Signed) VerifySignature() error {
+	if s.Calibration.SetBy == "" || s.Calibration.SetAt == 0 {
+		return fmt.Errorf("calibration must record set_by and set_at")
+	}
+	for name, v := range s.Calibration.Offsets {
+		if name == "" || strings.ContainsAny(name, "|;=") || v != v {
+			return fmt.Errorf("invalid offset entry %q", name)
+		}
+	}
+	pub, err := hex.DecodeString(s.Signer)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid calibration signer")
+	}
+	sig, err := hex.DecodeString(s.Signature)
+	if err != nil || !ed25519.Verify(pub, s.Calibration.Message(), sig) {
+		return fmt.Errorf("calibration signature does not verify")
+	}
+	return nil
+}
+
+// Versions remembers the highest calibration version accepted from each
+// source (an oracle node, say), so an older signed calibration cannot be
+// replayed once a newer one has been seen.
+type Versions struct {
+	mu   sync.Mutex
+	last map[string]int
+}
+
+// Accept records version for source, refusing one below the last accepted.
+func (v *Versions) Accept(source string, version int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if last, ok := v.last[source]; ok && version < last {
+		return fmt.Errorf("calibration version %d is older than version %d already accepted", version, last)
+	}
+	if v.last == nil {
+		v.last = map[string]int{}
+	}
+	v.last[source] = version
+	return nil
+}
+
+// Offset returns the correction for source, 0 if none is set.
+func (s *Signed) Offset(source string) float64 {
+	if s == nil {
+		return 0
+	}
+	return s.Calibration.Offsets[source]
+}
+
+// Load reads and verifies a signed calibration file.
+func Load(path string, trusted []string) (*Signed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Signed
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("malformed calibration file: %w", err)
+	}
+	if err := s.Verify(trusted); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,89 @@
+package calibration
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func testKey(seed string) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed([]byte(strings.Repeat(seed, ed25519.SeedSize)))
+}
+
+func pub(key ed25519.PrivateKey) string {
+	return hex.EncodeToString(key.Public().(ed25519.PublicKey))
+}
+
+func TestMessageFieldsCannotShift(t *testing.T) {
+	a := Calibration{Version: 1, SetBy: "ops|1700000000", SetAt: 1, Reason: "x"}
+	b := Calibration{Version: 1, SetBy: "ops", SetAt: 1, Reason: "1700000000|x"}
+	if bytes.Equal(a.Message(), b.Message()) {
+		t.Fatalf("distinct calibrations share a message: %s", a.Message())
+	}
+	c := Calibration{Version: 2, SetBy: "ops", SetAt: 1700000000, Reason: "drift", Offsets: map[string]float64{"b": 0.5, "a": -0.35}}
+	want := "AURUM|calibration|v1|2|1700000000|3:ops|5:drift|a=-0.35;b=0.5"
+	if got := string(c.Message()); got != want {
+		t.Errorf("Message() = %q, want %q", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	authority, other := testKey("a"), testKey("o")
+	offsets := Calibration{Version: 1, SetBy: "ops", SetAt: 1700000000, Offsets: map[string]float64{"Swissquote": -0.35}}
+	zeros := Calibration{Version: 1, SetBy: "ops", SetAt: 1700000000, Offsets: map[string]float64{"Swissquote": 0}}
+	tampered := Sign(offsets, authority)
+	tampered.Calibration.Offsets = map[string]float64{"Swissquote": 5}
+
+	tests := []struct {
+		name    string
+		signed  Signed
+		trusted []string
+		wantErr string
+	}{
+		{"trusted signer", Sign(offsets, authority), []string{pub(authority)}, ""},
+		{"trusted signer, any case", Sign(offsets, authority), []string{" " + strings.ToUpper(pub(authority))}, ""},
+		{"untrusted signer", Sign(offsets, other), []string{pub(authority)}, "not trusted"},
+		{"no signers, non-zero offset", Sign(offsets, authority), nil, "no calibration signers"},
+		{"no signers, zero offsets", Sign(zeros, other), nil, ""},
+		{"tampered offsets", tampered, []string{pub(authority)}, "does not verify"},
+		{"missing set_by", Sign(Calibration{Version: 1, SetAt: 1}, authority), []string{pub(authority)}, "set_by"},
+		{"bad source name", Sign(Calibration{Version: 1, SetBy: "ops", SetAt: 1, Offsets: map[string]float64{"a=b": 1}}, authority), []string{pub(authority)}, "invalid offset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.signed.Verify(tt.trusted)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVersionsRefuseReplay(t *testing.T) {
+	var v Versions
+	steps := []struct {
+		source  string
+		version int
+		ok      bool
+	}{
+		{"node-1", 3, true},
+		{"node-1", 3, true},
+		{"node-1", 2, false},
+		{"node-2", 1, true},
+		{"node-1", 4, true},
+		{"node-1", 3, false},
+	}
+	for i, s := range steps {
+		if err := v.Accept(s.source, s.version); (err == nil) != s.ok {
+			t.Errorf("step %d: Accept(%s, %d) error = %v, want ok=%v", i, s.source, s.version, err, s.ok)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+// Package canonjson implements the JSON Canonicalization Scheme (RFC 8785),
+// the byte form AURUM transaction hashes are computed over. Any JCS
+// implementation reproduces the same bytes; vectors.json in this directory
+// lists inputs with their canonical form and SHA-256 for checking one.
+// Input must be I-JSON (RFC 7493), which JCS assumes: documents another
+// implementation could read differently are refused, not canonicalized.
+package canonjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Canonicalize re-encodes a JSON document in canonical form: object keys
+// sorted by UTF-16 code units, no whitespace, minimal string escaping and
+// numbers written as ECMAScript writes an IEEE 754 double. Invalid UTF-8,
+// escapes of unpaired UTF-16 surrogates, duplicate object keys and integers
+// outside +/-(2^53-1) are refused.
+func Canonicalize(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("invalid UTF-8")
+	}
+	if err := checkSurrogates(data); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, fmt.Errorf("trailing data after JSON value")
+	}
+	var buf bytes.Buffer
+	if err := encode(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Marshal encodes v with encoding/json and canonicalizes the result.
+func Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Canonicalize(data)
+}
+
+// decodeValue reads the next value from dec like Decode into an
+// interface{}, but refuses an object that repeats a key (after unescaping)
+// where Decode would keep the last one.
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case json.Delim('{'):
+		obj := map[string]interface{}{}
+		for dec.More() {
+			kt, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			k := kt.(string)
+			if _, dup := obj[k]; dup {
+				return nil, fmt.Errorf("duplicate object key %q", k)
+			}
+			if obj[k], err = decodeValue(dec); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	}
+	return t, nil
+}
+
+// checkSurrogates refuses \u escapes of a UTF-16 surrogate that is not
+// part of a high-low pair. encoding/json replaces those with U+FFFD, so
+// different documents would canonicalize to the same bytes. Backslashes
+// only occur in strings, so the escapes are found without tracking them.
+func checkSurrogates(data []byte) error {
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\\' {
+			continue
+		}
+		r, ok := unicodeEscape(data[i:])
+		if !ok {
+			i++ // skip the escaped byte, which may be a backslash
+			continue
+		}
+		i += 5
+		if !utf16.IsSurrogate(r) {
+			continue
+		}
+		if lo, ok := unicodeEscape(data[i+1:]); ok && r < 0xdc00 && lo >= 0xdc00 && lo <= 0xdfff {
+			i += 6
+			continue
+		}
+		return fmt.Errorf("unpaired surrogate \\u%04x", r)
+	}
+	return nil
+}
+
+// unicodeEscape decodes a \uXXXX escape at the start of b.
+func unicodeEscape(b []byte) (rune, bool) {
+	if len(b) < 6 || b[0] != '\\' || b[1] != 'u' {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(b[2:6]), 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	return rune(n), true
+}
+
+func encode(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case string:
+		writeString(buf, v)
+	case json.Number:
+		s, err := formatNumber(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encode(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeString(buf, k)
+			buf.WriteByte(':')
+			if err := encode(buf, v[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected JSON value %T", v)
+	}
+	return nil
+}
+
+// lessUTF16 orders strings by their UTF-16 code units, as RFC 8785 requires.
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// writeString escapes only '"', '\' and control characters; everything
+// else is written as UTF-8.
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// formatNumber writes n as ECMAScript's Number.prototype.toString writes
+// the nearest double. Integers outside +/-(2^53-1) (the I-JSON range) are
+// refused rather than silently rounded.
+func formatNumber(n json.Number) (string, error) {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsInf(f, 0) {
+		return "", fmt.Errorf("number %s out of range", n)
+	}
+	if !strings.ContainsAny(string(n), ".eE") {
+		if i, err := strconv.ParseInt(string(n), 10, 64); err != nil || i > 1<<53-1 || i < -(1<<53-1) {
+			return "", fmt.Errorf("integer %s is not exactly representable as a double", n)
+		}
+	}
+	if f == 0 {
+		return "0", nil // also -0
+	}
+
+	// Shortest round-trip digits d1.d2...dk and exponent: value = 0.d1...dk * 10^pos
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+	mant, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mant, ".", "", 1)
+	e, _ := strconv.Atoi(exp)
+	k, pos := len(digits), e+1
+
+	var s string
+	switch {
+	case k <= pos && pos <= 21:
+		s = digits + strings.Repeat("0", pos-k)
+	case 0 < pos && pos <= 21:
+		s = digits[:pos] + "." + digits[pos:]
+	case -6 < pos && pos <= 0:
+		s = "0." + strings.Repeat("0", -pos) + digits
+	default:
+		s = digits[:1]
+		if k > 1 {
+			s += "." + digits[1:]
+		}
+		if pos-1 >= 0 {
+			s += "e+" + strconv.Itoa(pos-1)
+		} else {
+			s += "e-" + strconv.Itoa(1-pos)
+		}
+	}
+	return sign + s, nil
+}
@@ -0,0 +1,75 @@
+package canonjson
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestVectors(t *testing.T) {
+	raw, err := os.ReadFile("vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		Vectors []struct {
+			Name      string `json:"name"`
+			Input     string `json:"input"`
+			Canonical string `json:"canonical"`
+			SHA256    string `json:"sha256"`
+			Error     bool   `json:"error"`
+		} `json:"vectors"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range file.Vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			got, err := Canonicalize([]byte(v.Input))
+			if v.Error {
+				if err == nil {
+					t.Fatalf("accepted as %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			sum := sha256.Sum256(got)
+			if string(got) != v.Canonical || hex.EncodeToString(sum[:]) != v.SHA256 {
+				t.Fatalf("got %s (sha256 %x), want %s", got, sum, v.Canonical)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"invalid UTF-8", "[\"\xff\"]"},
+		{"high surrogate at end of input", `"\ud800`},
+		{"high surrogate before a non-surrogate", `["\ud800A"]`},
+		{"two high surrogates", `["\ud83d\ud83d"]`},
+		{"trailing data", `{} {}`},
+		{"unterminated object", `{"a":1`},
+	}
+	for _, tt := range tests {
+		if got, err := Canonicalize([]byte(tt.input)); err == nil {
+			t.Errorf("%s: accepted as %s", tt.name, got)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	got, err := Marshal(map[string]interface{}{"b": []int{1, 2}, "a": "<&>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":"<&>","b":[1,2]}`; string(got) != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+}
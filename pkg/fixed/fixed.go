@@ -0,0 +1,142 @@
+// Package fixed carries AURUM prices as integers with an explicit decimal
+// scale, so a price hashes and compares the same in every language. A
+// Price of 265050000000 is 2650.5. Floats are only produced for display.
+package fixed
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	Scale = 8
+	One   = Price(100_000_000) // 10^Scale
+)
+
+// Price is a decimal value times One.
+type Price int64
+
+var (
+	bigOne  = big.NewInt(int64(One))
+	halfRat = big.NewRat(1, 2)
+)
+
+// Parse reads a decimal number as JSON writes it ("2650.5", "-0.35",
+// "1.5e3"); fractions and base prefixes are rejected. Digits past Scale are
+// rounded half away from zero.
+func Parse(s string) (Price, error) {
+	s = strings.TrimSpace(s)
+	if !isDecimal(s) {
+		return 0, fmt.Errorf("invalid decimal %q", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid decimal %q", s)
+	}
+	return FromRat(r)
+}
+
+func isDecimal(s string) bool {
+	digits := func(d string) bool {
+		for _, c := range d {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+		return d != ""
+	}
+	s = strings.TrimPrefix(s, "-")
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp := strings.TrimLeft(s[i+1:], "+-")
+		if len(s[i+1:])-len(exp) > 1 || !digits(exp) || len(exp) > 3 {
+			return false
+		}
+		s = s[:i]
+	}
+	intPart, frac, hasDot := strings.Cut(s, ".")
+	return digits(intPart) && (!hasDot || digits(frac))
+}
+
+// FromRat scales and rounds r half away from zero.
+func FromRat(r *big.Rat) (Price, error) {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(bigOne))
+	if scaled.Sign() < 0 {
+		scaled.Sub(scaled, halfRat)
+	} else {
+		scaled.Add(scaled, halfRat)
+	}
+	// Quo truncates toward zero, completing the rounding above
+	q := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("decimal %s out of range", r.FloatString(Scale))
+	}
+	return Price(q.Int64()), nil
+}
+
+// FromFloat takes f at its shortest decimal form, the digits a JSON
+// encoder would have written for it.
+func FromFloat(f float64) (Price, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("invalid number %v", f)
+	}
+	return Parse(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// Rat is the exact value of p.
+func (p Price) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(int64(p)), bigOne)
+}
+
+// Float is for display and statistics only.
+func (p Price) Float() float64 {
+	return float64(p) / float64(One)
+}
+
+// String is the exact decimal with trailing zeros removed.
+func (p Price) String() string {
+	s := p.Rat().FloatString(Scale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// Mul returns p*q, rounded half away from zero, or an error if the
+// product is out of range.
+func Mul(p, q Price) (Price, error) {
+	return FromRat(new(big.Rat).Mul(p.Rat(), q.Rat()))
+}
+
+// Div returns p/q, rounded half away from zero, or an error if q is 0 or
+// the quotient is out of range.
+func Div(p, q Price) (Price, error) {
+	if q == 0 {
+		return 0, fmt.Errorf("division of %s by zero", p)
+	}
+	return FromRat(new(big.Rat).Quo(p.Rat(), q.Rat()))
+}
+
+// Median of values; for an even count, the mean of the middle two
+// rounded half away from zero. 0 for no values.
+func Median(values []Price) Price {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]Price(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return Midpoint(sorted[mid-1], sorted[mid])
+}
+
+// Midpoint is (a+b)/2 rounded half away from zero.
+func Midpoint(a, b Price) Price {
+	r, _ := FromRat(new(big.Rat).Quo(new(big.Rat).Add(a.Rat(), b.Rat()), big.NewRat(2, 1)))
+	return r
+}
@@ -0,0 +1,116 @@
+package fixed
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Price
+		wantErr bool
+	}{
+		{"2650.5", 265050000000, false},
+		{"-0.35", -35000000, false},
+		{"1.5e3", 150000000000, false},
+		{"0.000000005", 1, false},   // half rounds away from zero
+		{"-0.000000005", -1, false}, // on both sides
+		{"0.0000000049", 0, false},
+		{"92233720368.54775807", math.MaxInt64, false},
+		{"92233720368.54775808", 0, true},
+		{"1/3", 0, true},
+		{"0x10", 0, true},
+		{"1e1000", 0, true},
+		{"", 0, true},
+		{".5", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Parse(%q) = %d, %v; want %d, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	for _, f := range []float64{math.NaN(), math.Inf(1), 1e12} {
+		if _, err := FromFloat(f); err == nil {
+			t.Errorf("FromFloat(%v) accepted", f)
+		}
+	}
+	if p, err := FromFloat(0.1); err != nil || p != 10000000 {
+		t.Errorf("FromFloat(0.1) = %d, %v", p, err)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		p    Price
+		want string
+	}{
+		{265050000000, "2650.5"},
+		{-35000000, "-0.35"},
+		{1, "0.00000001"},
+		{0, "0"},
+		{100 * One, "100"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.String(); got != tt.want {
+			t.Errorf("Price(%d).String() = %q, want %q", int64(tt.p), got, tt.want)
+		}
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      func(p, q Price) (Price, error)
+		p, q    Price
+		want    Price
+		wantErr string
+	}{
+		{"mul", Mul, 2000 * One, 92000000, 1840 * One, ""},
+		{"mul rounds half away", Mul, 15000000, 10000000, 1500000, ""}, // 0.15 * 0.1
+		{"mul negative", Mul, -3, 50000000, -2, ""},                    // -0.000000015 rounds to -0.00000002
+		{"mul overflow", Mul, 1e9 * One, 1e9 * One, 0, "out of range"},
+		{"div", Div, One, 80000000, 125000000, ""},
+		{"div repeating", Div, One, 3 * One, 33333333, ""},
+		{"div by zero", Div, One, 0, 0, "by zero"},
+		{"div overflow", Div, 1e10 * One, 1, 0, "out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.op(tt.p, tt.q)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("got %d, %v; want %d", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values []Price
+		want   Price
+	}{
+		{nil, 0},
+		{[]Price{7}, 7},
+		{[]Price{3, 1, 2}, 2},
+		{[]Price{4, 1, 3, 2}, 3}, // (2+3)/2 = 2.5 rounds away from zero
+		{[]Price{-4, -1}, -3},    // -2.5 likewise
+		{[]Price{10, 20, 30, 40}, 25},
+	}
+	for _, tt := range tests {
+		if got := Median(tt.values); got != tt.want {
+			t.Errorf("Median(%v) = %d, want %d", tt.values, got, tt.want)
+		}
+	}
+}
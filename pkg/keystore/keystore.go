@@ -0,0 +1,213 @@
+// Package keystore reads and writes AURUM ed25519 node keys: passphrase-
+// encrypted keystores (scrypt + AES-256-GCM) and legacy unencrypted PEM.
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keystoreVersion = 1
+	// scrypt parameters: N=2^18, r=8, p=1 (~250ms, 256MB on a typical server)
+	scryptN      = 1 << 18
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+
+	// Keystores are refused outside these bounds, so a tampered file can
+	// neither weaken the KDF nor make opening it exhaust memory or CPU
+	scryptMinN    = 1 << 15
+	scryptMaxN    = 1 << 20
+	scryptMaxR    = 16
+	scryptMaxP    = 4
+	scryptMaxMem  = 1 << 30 // 128*N*r bytes
+	minSaltLength = 16
+)
+
+// encryptedKeystore is the on-disk format for a passphrase-protected key:
+// the ed25519 seed sealed with AES-256-GCM under an scrypt-derived key.
+type encryptedKeystore struct {
+	Version int    `json:"version"`
+	Pubkey  string `json:"pubkey"`
+	KDF     struct {
+		Name string `json:"name"`
+		N    int    `json:"n"`
+		R    int    `json:"r"`
+		P    int    `json:"p"`
+		Salt string `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce string `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// Passphrase reads AURUM_KEY_PASSPHRASE_FILE, then AURUM_KEY_PASSPHRASE.
+func Passphrase() (string, error) {
+	if path := os.Getenv("AURUM_KEY_PASSPHRASE_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read passphrase file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return os.Getenv("AURUM_KEY_PASSPHRASE"), nil
+}
+
+// Seal encrypts key under passphrase and returns the keystore JSON.
+func Seal(key ed25519.PrivateKey, passphrase string) ([]byte, error) {
+	var ks encryptedKeystore
+	ks.Version = keystoreVersion
+	ks.Pubkey = hex.EncodeToString(key.Public().(ed25519.PublicKey))
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	derived, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := aes.NewCipher(derived)
+	gcm, _ := cipher.NewGCM(block)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	// The public key is bound as associated data so it cannot be swapped
+	sealed := gcm.Seal(nil, nonce, key.Seed(), []byte(ks.Pubkey))
+
+	ks.KDF.Name, ks.KDF.N, ks.KDF.R, ks.KDF.P = "scrypt", scryptN, scryptR, scryptP
+	ks.KDF.Salt = hex.EncodeToString(salt)
+	ks.Cipher.Name = "aes-256-gcm"
+	ks.Cipher.Nonce = hex.EncodeToString(nonce)
+	ks.Ciphertext = hex.EncodeToString(sealed)
+	return json.MarshalIndent(ks, "", "  ")
+}
+
+// Open decrypts a keystore produced by Seal.
+func Open(data []byte, passphrase string) (ed25519.PrivateKey, error) {
+	var ks encryptedKeystore
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("malformed keystore: %w", err)
+	}
+	if ks.Version != keystoreVersion || ks.KDF.Name != "scrypt" || ks.Cipher.Name != "aes-256-gcm" {
+		return nil, fmt.Errorf("unsupported keystore (version %d, %s, %s)", ks.Version, ks.KDF.Name, ks.Cipher.Name)
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("keystore is encrypted: set AURUM_KEY_PASSPHRASE or AURUM_KEY_PASSPHRASE_FILE")
+	}
+	if err := checkKDF(ks.KDF.N, ks.KDF.R, ks.KDF.P); err != nil {
+		return nil, err
+	}
+	salt, err1 := hex.DecodeString(ks.KDF.Salt)
+	nonce, err2 := hex.DecodeString(ks.Cipher.Nonce)
+	sealed, err3 := hex.DecodeString(ks.Ciphertext)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, fmt.Errorf("malformed keystore encoding")
+	}
+	if len(salt) < minSaltLength {
+		return nil, fmt.Errorf("keystore salt is %d bytes, want at least %d", len(salt), minSaltLength)
+	}
+
+	derived, err := scrypt.Key([]byte(passphrase), salt, ks.KDF.N, ks.KDF.R, ks.KDF.P, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := aes.NewCipher(derived)
+	gcm, _ := cipher.NewGCM(block)
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("malformed keystore nonce")
+	}
+	seed, err := gcm.Open(nil, nonce, sealed, []byte(ks.Pubkey))
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted keystore")
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("keystore holds a %d-byte seed", len(seed))
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	if hex.EncodeToString(key.Public().(ed25519.PublicKey)) != ks.Pubkey {
+		return nil, fmt.Errorf("keystore public key does not match its secret")
+	}
+	return key, nil
+}
+
+// checkKDF refuses scrypt parameters outside the accepted bounds.
+func checkKDF(n, r, p int) error {
+	switch {
+	case n < scryptMinN || n > scryptMaxN || n&(n-1) != 0:
+		return fmt.Errorf("keystore scrypt N=%d must be a power of two from %d to %d", n, scryptMinN, scryptMaxN)
+	case r < 1 || r > scryptMaxR:
+		return fmt.Errorf("keystore scrypt r=%d must be from 1 to %d", r, scryptMaxR)
+	case p < 1 || p > scryptMaxP:
+		return fmt.Errorf("keystore scrypt p=%d must be from 1 to %d", p, scryptMaxP)
+	case 128*n*r > scryptMaxMem:
+		return fmt.Errorf("keystore scrypt N=%d r=%d needs more than %d MiB", n, r, scryptMaxMem>>20)
+	}
+	return nil
+}
+
+// ParsePEM reads the legacy unencrypted PKCS#8 PEM format.
+func ParsePEM(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("no PKCS#8 PRIVATE KEY block found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse PKCS#8: %w", err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key is %T, not ed25519", key)
+	}
+	return edKey, nil
+}
+
+// ReadFile loads a keystore or legacy PEM key and reports whether it was
+// encrypted. It never creates one: a missing or unreadable key is fatal
+// for the caller.
+func ReadFile(path string) (ed25519.PrivateKey, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "-----BEGIN") {
+		key, err := ParsePEM(data)
+		return key, false, err
+	}
+	passphrase, err := Passphrase()
+	if err != nil {
+		return nil, true, err
+	}
+	key, err := Open(data, passphrase)
+	return key, true, err
+}
+
+// WriteFile creates path exclusively so an existing identity is never clobbered.
+func WriteFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
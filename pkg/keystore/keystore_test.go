@@ -0,0 +1,66 @@
+package keystore
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testKey() ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed([]byte(strings.Repeat("k", ed25519.SeedSize)))
+}
+
+func TestSealOpen(t *testing.T) {
+	key := testKey()
+	data, err := Seal(key, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Open(data, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(key) {
+		t.Fatal("opened key differs from the sealed one")
+	}
+	if _, err := Open(data, "battery staple"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Fatalf("wrong passphrase: error = %v", err)
+	}
+	if _, err := Open(data, ""); err == nil {
+		t.Fatal("empty passphrase accepted")
+	}
+}
+
+// Bounds are checked before scrypt runs, so these cost nothing.
+func TestOpenRejectsKDFParams(t *testing.T) {
+	base := encryptedKeystore{Version: keystoreVersion, Pubkey: "00"}
+	base.KDF.Name, base.KDF.N, base.KDF.R, base.KDF.P = "scrypt", scryptN, scryptR, scryptP
+	base.KDF.Salt = strings.Repeat("ab", 32)
+	base.Cipher.Name = "aes-256-gcm"
+
+	tests := []struct {
+		name    string
+		edit    func(ks *encryptedKeystore)
+		wantErr string
+	}{
+		{"N too small", func(ks *encryptedKeystore) { ks.KDF.N = 1 << 10 }, "scrypt N"},
+		{"N too large", func(ks *encryptedKeystore) { ks.KDF.N = 1 << 30 }, "scrypt N"},
+		{"N not a power of two", func(ks *encryptedKeystore) { ks.KDF.N = 1<<18 + 1 }, "scrypt N"},
+		{"r zero", func(ks *encryptedKeystore) { ks.KDF.R = 0 }, "scrypt r"},
+		{"r too large", func(ks *encryptedKeystore) { ks.KDF.R = 1024 }, "scrypt r"},
+		{"p too large", func(ks *encryptedKeystore) { ks.KDF.P = 1 << 20 }, "scrypt p"},
+		{"memory too large", func(ks *encryptedKeystore) { ks.KDF.N, ks.KDF.R = 1<<20, 16 }, "MiB"},
+		{"short salt", func(ks *encryptedKeystore) { ks.KDF.Salt = "abcd" }, "salt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := base
+			tt.edit(&ks)
+			data, _ := json.Marshal(ks)
+			if _, err := Open(data, "pass"); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
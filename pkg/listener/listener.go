@@ -0,0 +1,250 @@
+// Package listener provides the HTTP(S) listener shared by the AURUM binaries:
+// listen address, TLS, optional client certificates and hot certificate reload.
+package listener
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultReloadInterval = 30 * time.Second
+
+// Config describes how a binary listens. Leaving CertFile/KeyFile empty
+// serves plain HTTP.
+type Config struct {
+	Addr          string `json:"addr"`
+	CertFile      string `json:"cert_file"`
+	KeyFile       string `json:"key_file"`
+	ClientCAFile  string `json:"client_ca_file"`
+	ClientAuth    string `json:"client_auth"` // "none", "optional" or "require"
+	ReloadSeconds int    `json:"reload_seconds"`
+}
+
+// FromEnv reads LISTEN_ADDR, TLS_CERT_FILE, TLS_KEY_FILE, TLS_CLIENT_CA_FILE,
+// TLS_CLIENT_AUTH and TLS_RELOAD_SECONDS.
+func FromEnv(defaultAddr string) Config {
+	cfg := Config{
+		Addr:         os.Getenv("LISTEN_ADDR"),
+		CertFile:     os.Getenv("TLS_CERT_FILE"),
+		KeyFile:      os.Getenv("TLS_KEY_FILE"),
+		ClientCAFile: os.Getenv("TLS_CLIENT_CA_FILE"),
+		ClientAuth:   os.Getenv("TLS_CLIENT_AUTH"),
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = defaultAddr
+	}
+	if v, err := strconv.Atoi(os.Getenv("TLS_RELOAD_SECONDS")); err == nil {
+		cfg.ReloadSeconds = v
+	}
+	return cfg
+}
+
+func (c Config) TLSEnabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+func (c Config) clientAuthType() (tls.ClientAuthType, error) {
+	switch c.ClientAuth {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "optional":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return tls.NoClientCert, fmt.Errorf("unknown client_auth %q", c.ClientAuth)
+}
+
+func (c Config) validate() error {
+	if c.Addr == "" {
+		return fmt.Errorf("listen address is empty")
+	}
+	if !c.TLSEnabled() {
+		if c.ClientCAFile != "" || (c.ClientAuth != "" && c.ClientAuth != "none") {
+			return fmt.Errorf("client certificates require TLS to be enabled")
+		}
+		return nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("both cert_file and key_file are required for TLS")
+	}
+	authType, err := c.clientAuthType()
+	if err != nil {
+		return err
+	}
+	if authType != tls.NoClientCert && c.ClientCAFile == "" {
+		return fmt.Errorf("client_auth %q requires client_ca_file", c.ClientAuth)
+	}
+	return nil
+}
+
+// ListenAndServe serves handler according to cfg and blocks like http.ListenAndServe.
+func ListenAndServe(cfg Config, handler http.Handler) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+	if !cfg.TLSEnabled() {
+		return srv.ListenAndServe()
+	}
+
+	reloader, err := newReloader(cfg)
+	if err != nil {
+		return err
+	}
+	reloader.start()
+	srv.TLSConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: reloader.configForClient,
+	}
+	return srv.ListenAndServeTLS("", "")
+}
+
+// --- Certificate Reload ---
+
+type reloader struct {
+	cfg      Config
+	authType tls.ClientAuthType
+	interval time.Duration
+
+	mu      sync.RWMutex
+	current *tls.Config
+	modTime time.Time
+}
+
+func newReloader(cfg Config) (*reloader, error) {
+	authType, _ := cfg.clientAuthType()
+	r := &reloader{cfg: cfg, authType: authType, interval: defaultReloadInterval}
+	if cfg.ReloadSeconds > 0 {
+		r.interval = time.Duration(cfg.ReloadSeconds) * time.Second
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *reloader) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current, nil
+}
+
+// latestModTime is the newest mtime across the cert, key and CA files.
+func (r *reloader) latestModTime() time.Time {
+	var latest time.Time
+	for _, path := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+func (r *reloader) reload() error {
+	modTime := r.latestModTime()
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+	next := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   r.authType,
+	}
+	if r.cfg.ClientCAFile != "" {
+		pool, err := LoadCertPool(r.cfg.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		next.ClientCAs = pool
+	}
+
+	r.mu.Lock()
+	r.current = next
+	r.modTime = modTime
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *reloader) start() {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		for range ticker.C {
+			r.mu.RLock()
+			unchanged := !r.latestModTime().After(r.modTime)
+			r.mu.RUnlock()
+			if unchanged {
+				continue
+			}
+			// Keep serving the old certificate if the new files are half-written
+			if err := r.reload(); err != nil {
+				log.Printf("⚠️  TLS reload failed: %v", err)
+				continue
+			}
+			log.Printf("🔐 TLS certificates reloaded")
+		}
+	}()
+}
+
+// --- Helpers ---
+
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// ClientTLS builds a client-side TLS config for talking to a listener
+// that uses a private CA and/or requires client certificates. It returns
+// nil when nothing is configured so the system defaults apply.
+func ClientTLS(caFile, certFile, keyFile string) (*tls.Config, error) {
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pool, err := LoadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// PeerCommonName returns the subject CN of a verified client certificate.
+func PeerCommonName(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName, true
+}
@@ -0,0 +1,176 @@
+package listener
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA issues certificates for the tests below.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for cn, valid for localhost.
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPeerCommonName(t *testing.T) {
+	dir := t.TempDir()
+	ca, other := newTestCA(t, "aurum test CA"), newTestCA(t, "someone else")
+	serverCert, serverKey := ca.issue(t, "gateway", x509.ExtKeyUsageServerAuth)
+	cfg := Config{
+		Addr:         "127.0.0.1:0",
+		CertFile:     writeFile(t, dir, "server.pem", serverCert),
+		KeyFile:      writeFile(t, dir, "server.key", serverKey),
+		ClientCAFile: writeFile(t, dir, "ca.pem", ca.pem),
+		ClientAuth:   "optional",
+	}
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := newReloader(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		cn, ok := PeerCommonName(req)
+		if !ok {
+			cn = "-"
+		}
+		io.WriteString(w, cn)
+	}))
+	srv.TLS = &tls.Config{GetConfigForClient: r.configForClient}
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := func(certPEM, keyPEM []byte) *http.Client {
+		tc := &tls.Config{RootCAs: roots}
+		if certPEM != nil {
+			pair, err := tls.X509KeyPair(certPEM, keyPEM)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.Certificates = []tls.Certificate{pair}
+		}
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: tc}}
+	}
+
+	tests := []struct {
+		name   string
+		client *http.Client
+		want   string
+	}{
+		{"verified certificate", client(ca.issue(t, "institution-c", x509.ExtKeyUsageClientAuth)), "institution-c"},
+		{"no certificate", client(nil, nil), "-"},
+		// Clients only offer certificates from the CAs the server names
+		{"certificate from another CA", client(other.issue(t, "institution-c", x509.ExtKeyUsageClientAuth)), "-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.client.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.want {
+				t.Errorf("CN = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{"plain HTTP", Config{Addr: ":80"}, ""},
+		{"mTLS", Config{Addr: ":443", CertFile: "c", KeyFile: "k", ClientCAFile: "ca", ClientAuth: "require"}, ""},
+		{"client CA without TLS", Config{Addr: ":80", ClientCAFile: "ca"}, "require TLS"},
+		{"client auth without CA", Config{Addr: ":443", CertFile: "c", KeyFile: "k", ClientAuth: "optional"}, "requires client_ca_file"},
+		{"unknown client auth", Config{Addr: ":443", CertFile: "c", KeyFile: "k", ClientAuth: "maybe"}, "unknown client_auth"},
+		{"key without certificate", Config{Addr: ":443", KeyFile: "k"}, "both cert_file and key_file"},
+	}
+	for _, tt := range tests {
+		err := tt.cfg.validate()
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
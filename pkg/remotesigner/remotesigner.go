@@ -0,0 +1,164 @@
+// Package remotesigner is the wire protocol between an aggregator and the
+// standalone aurum-signer daemon, which holds the node key outside the
+// internet-facing process (in the spirit of Tendermint's KMS).
+//
+// The daemon only signs the two AURUM message types: block header
+// commitments, for which it enforces double-sign protection by height, and
+// validator change approvals. Transport is HTTP over a Unix socket or mTLS.
+package remotesigner
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerPrefix   = "AURUM|v1|"
+	approvalPrefix = "AURUM|validator-change|v1|"
+
+	SignPath   = "/v1/sign"
+	PubkeyPath = "/v1/pubkey"
+)
+
+// SignRequest carries the exact bytes to sign; the daemon parses them itself
+// rather than trusting any height the caller claims.
+type SignRequest struct {
+	Message []byte `json:"message"`
+}
+
+type SignResponse struct {
+	Pubkey    string `json:"pubkey"`
+	Signature string `json:"signature"`
+}
+
+// HeaderMessage is the block header commitment every signer signs.
+// Format: AURUM|v1|Index|PrevHash|MerkleRoot
+func HeaderMessage(index int64, prevHash, merkleRoot string) []byte {
+	return []byte(fmt.Sprintf("%s%d|%s|%s", headerPrefix, index, prevHash, merkleRoot))
+}
+
+// ParseHeaderMessage returns the height of a well-formed header commitment.
+func ParseHeaderMessage(msg []byte) (int64, bool) {
+	s := string(msg)
+	if !strings.HasPrefix(s, headerPrefix) {
+		return 0, false
+	}
+	parts := strings.Split(strings.TrimPrefix(s, headerPrefix), "|")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	height, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || height < 0 || strconv.FormatInt(height, 10) != parts[0] {
+		return 0, false
+	}
+	for _, h := range parts[1:] {
+		if b, err := hex.DecodeString(h); err != nil || len(b) != 32 {
+			return 0, false
+		}
+	}
+	return height, true
+}
+
+// IsApprovalMessage reports whether msg is a validator change approval.
+func IsApprovalMessage(msg []byte) bool {
+	return strings.HasPrefix(string(msg), approvalPrefix)
+}
+
+// --- Client ---
+
+// Client talks to an aurum-signer daemon. It satisfies the aggregator's
+// Signer interface.
+type Client struct {
+	baseURL string
+	http    *http.Client
+	pubkey  ed25519.PublicKey
+}
+
+// Dial connects to address, either "unix:/path/to/socket" or
+// "https://host:port" (tlsConfig must then carry a client certificate), and
+// fetches the daemon's public key. Plain http is refused.
+func Dial(address string, tlsConfig *tls.Config) (*Client, error) {
+	c := &Client{http: &http.Client{Timeout: 5 * time.Second}}
+	switch {
+	case strings.HasPrefix(address, "unix:"):
+		path := strings.TrimPrefix(strings.TrimPrefix(address, "unix:"), "//")
+		c.baseURL = "http://signer"
+		c.http.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}
+	case strings.HasPrefix(address, "https://"):
+		if tlsConfig == nil || len(tlsConfig.Certificates) == 0 {
+			return nil, fmt.Errorf("remote signer over TCP requires a client certificate")
+		}
+		c.baseURL = strings.TrimSuffix(address, "/")
+		c.http.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	default:
+		return nil, fmt.Errorf("signer address must be unix:/path or https://host:port, got %q", address)
+	}
+
+	var resp SignResponse
+	if err := c.do(http.MethodGet, PubkeyPath, nil, &resp); err != nil {
+		return nil, fmt.Errorf("fetch signer public key: %w", err)
+	}
+	pub, err := hex.DecodeString(resp.Pubkey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("signer returned invalid public key %q", resp.Pubkey)
+	}
+	c.pubkey = pub
+	return c, nil
+}
+
+func (c *Client) PublicKey() ed25519.PublicKey {
+	return c.pubkey
+}
+
+// Sign asks the daemon to sign msg and checks the result against its key,
+// so a misbehaving daemon cannot slip a bad signature into a block.
+func (c *Client) Sign(msg []byte) ([]byte, error) {
+	var resp SignResponse
+	if err := c.do(http.MethodPost, SignPath, SignRequest{Message: msg}, &resp); err != nil {
+		return nil, err
+	}
+	sig, err := hex.DecodeString(resp.Signature)
+	if err != nil || !ed25519.Verify(c.pubkey, msg, sig) {
+		return nil, fmt.Errorf("remote signer returned an invalid signature")
+	}
+	return sig, nil
+}
+
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote signer: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,132 @@
+package remotesigner
+
+import (
+	"crypto/ed25519"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSigner is a local stand-in for the aurum-signer daemon, served over a
+// Unix socket. corrupt makes it return signatures over the wrong message.
+type fakeSigner struct {
+	key     ed25519.PrivateKey
+	corrupt bool
+}
+
+func (f *fakeSigner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pub := hex.EncodeToString(f.key.Public().(ed25519.PublicKey))
+	switch r.URL.Path {
+	case PubkeyPath:
+		json.NewEncoder(w).Encode(SignResponse{Pubkey: pub})
+	case SignPath:
+		var req SignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if _, ok := ParseHeaderMessage(req.Message); !ok && !IsApprovalMessage(req.Message) {
+			http.Error(w, "not an AURUM header or approval message", http.StatusBadRequest)
+			return
+		}
+		msg := req.Message
+		if f.corrupt {
+			msg = append([]byte("x"), msg...)
+		}
+		json.NewEncoder(w).Encode(SignResponse{Pubkey: pub, Signature: hex.EncodeToString(ed25519.Sign(f.key, msg))})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// startFake serves f on a fresh socket and returns its Dial address.
+func startFake(t *testing.T, f *fakeSigner) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: f}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return "unix:" + path
+}
+
+func TestClientSign(t *testing.T) {
+	key := ed25519.NewKeyFromSeed([]byte(strings.Repeat("s", ed25519.SeedSize)))
+	header := HeaderMessage(7, strings.Repeat("ab", 32), strings.Repeat("cd", 32))
+
+	c, err := Dial(startFake(t, &fakeSigner{key: key}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.PublicKey().Equal(key.Public()) {
+		t.Fatal("client reports the wrong public key")
+	}
+	sig, err := c.Sign(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(c.PublicKey(), header, sig) {
+		t.Fatal("signature does not verify")
+	}
+	if _, err := c.Sign([]byte("anything else")); err == nil {
+		t.Fatal("signer accepted a message that is not an AURUM header or approval")
+	}
+
+	bad, err := Dial(startFake(t, &fakeSigner{key: key, corrupt: true}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bad.Sign(header); err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Fatalf("corrupt signer: error = %v", err)
+	}
+}
+
+func TestDialAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		tls     *tls.Config
+		wantErr string
+	}{
+		{"plain http", "http://127.0.0.1:1", nil, "must be unix"},
+		{"https without client certificate", "https://127.0.0.1:1", &tls.Config{}, "client certificate"},
+		{"missing socket", "unix:" + filepath.Join(t.TempDir(), "none.sock"), nil, "public key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Dial(tt.address, tt.tls); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseHeaderMessage(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	tests := []struct {
+		msg    string
+		height int64
+		ok     bool
+	}{
+		{string(HeaderMessage(42, hash, hash)), 42, true},
+		{"AURUM|v1|042|" + hash + "|" + hash, 0, false},
+		{"AURUM|v1|-1|" + hash + "|" + hash, 0, false},
+		{"AURUM|v1|1|" + hash, 0, false},
+		{"AURUM|v1|1|" + hash + "|zz", 0, false},
+		{"AURUM|validator-change|v1|x", 0, false},
+	}
+	for _, tt := range tests {
+		height, ok := ParseHeaderMessage([]byte(tt.msg))
+		if height != tt.height || ok != tt.ok {
+			t.Errorf("ParseHeaderMessage(%q) = %d, %v; want %d, %v", tt.msg, height, ok, tt.height, tt.ok)
+		}
+	}
+}
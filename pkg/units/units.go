@@ -0,0 +1,56 @@
+// Package units converts gold prices between mass units. The ledger prices
+// gold per troy ounce; conversions use exact decimal arithmetic so every
+// binary reports the same digits for the same block.
+package units
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+// Unit is a mass unit defined exactly in grams.
+type Unit struct {
+	Name  string // canonical name, as reported in responses
+	Grams string // exact grams per unit
+}
+
+var (
+	TroyOunce = Unit{Name: "troy_oz", Grams: "31.1034768"}
+	Gram      = Unit{Name: "g", Grams: "1"}
+	Kilogram  = Unit{Name: "kg", Grams: "1000"}
+	Tola      = Unit{Name: "tola", Grams: "11.6638038"} // 3/8 troy ounce
+)
+
+var aliases = map[string]Unit{
+	"troy_oz": TroyOunce, "oz": TroyOunce, "ozt": TroyOunce, "troy_ounce": TroyOunce,
+	"g": Gram, "gram": Gram,
+	"kg": Kilogram, "kilogram": Kilogram,
+	"tola": Tola,
+}
+
+// Parse resolves a unit name or alias; empty means troy ounce.
+func Parse(name string) (Unit, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return TroyOunce, nil
+	}
+	u, ok := aliases[name]
+	if !ok {
+		return Unit{}, fmt.Errorf("unknown unit %q (use troy_oz, g, kg or tola)", name)
+	}
+	return u, nil
+}
+
+// FromTroyOunce converts a per-troy-ounce price to a price per u, rounded
+// to the fixed-point scale (halves away from zero). It fails when the
+// converted price is out of the fixed-point range.
+func FromTroyOunce(perOz fixed.Price, u Unit) (fixed.Price, error) {
+	grams, _ := new(big.Rat).SetString(u.Grams)
+	oz, _ := new(big.Rat).SetString(TroyOunce.Grams)
+	r := perOz.Rat()
+	r.Mul(r, grams).Quo(r, oz)
+	return fixed.FromRat(r)
+}
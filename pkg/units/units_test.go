@@ -0,0 +1,60 @@
+package units
+
+import (
+	"math"
+	"testing"
+
+	"aurum-oracle/pkg/fixed"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Unit
+		wantErr bool
+	}{
+		{"", TroyOunce, false},
+		{"OZ", TroyOunce, false},
+		{" troy_ounce ", TroyOunce, false},
+		{"gram", Gram, false},
+		{"kg", Kilogram, false},
+		{"tola", Tola, false},
+		{"lb", Unit{}, true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Parse(%q) = %v, %v; want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFromTroyOunce(t *testing.T) {
+	tests := []struct {
+		perOz string
+		unit  Unit
+		want  string
+	}{
+		{"2650.5", TroyOunce, "2650.5"},
+		{"2650.5", Gram, "85.21555378"}, // 85.2155537801...
+		{"2650.5", Kilogram, "85215.55378015"},
+		{"2650.5", Tola, "993.9375"}, // exactly 3/8
+		{"31.1034768", Gram, "1"},
+		{"-31.1034768", Gram, "-1"},
+		{"0.00000001", Kilogram, "0.00000032"}, // 0.0000003215... rounds down
+	}
+	for _, tt := range tests {
+		perOz, err := fixed.Parse(tt.perOz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := FromTroyOunce(perOz, tt.unit)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("FromTroyOunce(%s, %s) = %s, %v; want %s", tt.perOz, tt.unit.Name, got, err, tt.want)
+		}
+	}
+	// A kilogram costs 32 times an ounce, which can leave the int64 range
+	if _, err := FromTroyOunce(fixed.Price(math.MaxInt64/2), Kilogram); err == nil {
+		t.Error("overflowing conversion returned no error")
+	}
+}